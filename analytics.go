@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mjh1207/chirpy/internal/analytics"
+	"github.com/mjh1207/chirpy/internal/database"
+)
+
+const (
+	// statsFlushInterval is how often buffered engagement counts are
+	// written to chirp_stats_hourly. Counts still in the buffer are lost if
+	// the server stops.
+	statsFlushInterval = 30 * time.Second
+	// statsBufferKeys bounds how many chirp-hours are buffered between
+	// flushes.
+	statsBufferKeys = 100_000
+)
+
+// recordImpressions counts chirps shown to viewerID in a list. Authors
+// seeing their own chirps aren't counted.
+func (cfg *apiConfig) recordImpressions(viewerID uuid.UUID, chirps []database.Chirp) {
+	for _, chirp := range chirps {
+		if chirp.UserID != viewerID {
+			cfg.chirpStats.Record(chirp.ID, analytics.Impression)
+		}
+	}
+}
+
+// recordView counts chirp being fetched on its own by viewerID.
+func (cfg *apiConfig) recordView(viewerID uuid.UUID, chirp database.Chirp) {
+	if chirp.UserID != viewerID {
+		cfg.chirpStats.Record(chirp.ID, analytics.View)
+	}
+}
+
+// runStatsFlush writes buffered engagement counts to the database every
+// statsFlushInterval until ctx is cancelled.
+func (cfg *apiConfig) runStatsFlush(ctx context.Context) {
+	ticker := time.NewTicker(statsFlushInterval)
+	defer ticker.Stop()
+	var dropped int64
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if err := cfg.flushStats(ctx); err != nil {
+			log.Printf("Couldn't flush chirp stats: %v", err)
+		}
+		if total := cfg.chirpStats.Dropped(); total > dropped {
+			log.Printf("Dropped %d chirp stats events, the buffer is full", total-dropped)
+			dropped = total
+		}
+	}
+}
+
+// flushStats drains the buffer into chirp_stats_hourly in one statement. If
+// that fails the counts go back into the buffer for the next flush.
+func (cfg *apiConfig) flushStats(ctx context.Context) error {
+	counts := cfg.chirpStats.Drain()
+	if len(counts) == 0 {
+		return nil
+	}
+
+	params := database.AddChirpStatsParams{
+		ChirpIds:    make([]uuid.UUID, 0, len(counts)),
+		Hours:       make([]time.Time, 0, len(counts)),
+		Impressions: make([]int64, 0, len(counts)),
+		Views:       make([]int64, 0, len(counts)),
+		Likes:       make([]int64, 0, len(counts)),
+		Replies:     make([]int64, 0, len(counts)),
+	}
+	for key, c := range counts {
+		params.ChirpIds = append(params.ChirpIds, key.ChirpID)
+		params.Hours = append(params.Hours, key.Hour)
+		params.Impressions = append(params.Impressions, c.Impressions)
+		params.Views = append(params.Views, c.Views)
+		params.Likes = append(params.Likes, c.Likes)
+		params.Replies = append(params.Replies, c.Replies)
+	}
+
+	if err := cfg.db.AddChirpStats(ctx, params); err != nil {
+		cfg.chirpStats.Restore(counts)
+		return err
+	}
+	return nil
+}
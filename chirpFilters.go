@@ -0,0 +1,203 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mjh1207/chirpy/internal/database"
+)
+
+// maxFilterAuthors bounds how many authors can be listed in author_id or
+// exclude_author_id.
+const maxFilterAuthors = 100
+
+// Values of the replies filter.
+const (
+	repliesInclude = "include"
+	repliesOnly    = "only"
+	repliesExclude = "exclude"
+)
+
+// parseChirpFilters turns the query string of GET /api/chirps into the
+// parameters of FilterChirps. Invalid filters are returned as a *chirpError
+// naming the offending parameter.
+func (cfg *apiConfig) parseChirpFilters(ctx context.Context, query url.Values, viewerID uuid.UUID) (database.FilterChirpsParams, error) {
+	params := database.FilterChirpsParams{ViewerID: viewerID}
+
+	var err error
+	if params.AuthorIds, err = parseUUIDList(query, "author_id"); err != nil {
+		return params, err
+	}
+	if params.ExcludeAuthorIds, err = parseUUIDList(query, "exclude_author_id"); err != nil {
+		return params, err
+	}
+	// A single author's timeline starts with their pinned chirps
+	params.PinnedFirst = len(params.AuthorIds) == 1
+
+	if params.Since, err = parseTimeFilter(query, "since"); err != nil {
+		return params, err
+	}
+	if params.Until, err = parseTimeFilter(query, "until"); err != nil {
+		return params, err
+	}
+	if params.Since.Valid && params.Until.Valid && !params.Since.Time.Before(params.Until.Time) {
+		return params, filterError("until", "must be after since")
+	}
+
+	sinceChirp, err := cfg.parseChirpIDFilter(ctx, query, "since_id", viewerID)
+	if err != nil {
+		return params, err
+	}
+	if sinceChirp != nil {
+		params.SinceIDCreatedAt = sql.NullTime{Time: sinceChirp.CreatedAt, Valid: true}
+		params.SinceID = uuid.NullUUID{UUID: sinceChirp.ID, Valid: true}
+	}
+	maxChirp, err := cfg.parseChirpIDFilter(ctx, query, "max_id", viewerID)
+	if err != nil {
+		return params, err
+	}
+	if maxChirp != nil {
+		params.MaxIDCreatedAt = sql.NullTime{Time: maxChirp.CreatedAt, Valid: true}
+		params.MaxID = uuid.NullUUID{UUID: maxChirp.ID, Valid: true}
+	}
+
+	if params.HasMedia, err = parseBoolFilter(query, "has_media"); err != nil {
+		return params, err
+	}
+	if params.HasLinks, err = parseBoolFilter(query, "has_links"); err != nil {
+		return params, err
+	}
+
+	switch query.Get("replies") {
+	case "", repliesInclude:
+	case repliesOnly:
+		params.IsReply = sql.NullBool{Bool: true, Valid: true}
+	case repliesExclude:
+		params.IsReply = sql.NullBool{Bool: false, Valid: true}
+	default:
+		return params, filterError("replies", fmt.Sprintf("must be one of %s, %s or %s", repliesInclude, repliesOnly, repliesExclude))
+	}
+
+	if sort := query.Get("sort"); sort != "" && sort != "asc" && sort != "desc" {
+		return params, filterError("sort", "must be asc or desc")
+	}
+
+	return params, nil
+}
+
+// respondWithFilteredChirps lists the chirps matching filters, sorted as
+// the sort parameter asks. It backs GET /api/chirps and list timelines.
+func (cfg *apiConfig) respondWithFilteredChirps(w http.ResponseWriter, req *http.Request, viewerID uuid.UUID, filters database.FilterChirpsParams) {
+	chirps, err := cfg.db.FilterChirps(req.Context(), filters)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get chirps from database", err)
+		return
+	}
+
+	response, err := cfg.chirpsResponse(req.Context(), viewerID, chirps)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't build chirp response", err)
+		return
+	}
+	cfg.recordImpressions(viewerID, chirps)
+	// An author's pinned chirps stay at the top whichever way the rest are sorted
+	pinned := 0
+	if filters.PinnedFirst {
+		for pinned < len(response) && response[pinned].Pinned {
+			pinned++
+		}
+	}
+	if req.URL.Query().Get("sort") == "desc" {
+		unpinned := response[pinned:]
+		sort.Slice(unpinned, func(i, j int) bool { return unpinned[i].CreatedAt.After(unpinned[j].CreatedAt) })
+	}
+	respondWithJSON(w, http.StatusOK, response)
+}
+
+// parseUUIDList reads a list of IDs from a parameter that can be repeated,
+// comma-separated, or both. The result is never nil, as FilterChirps treats
+// a NULL array differently from an empty one.
+func parseUUIDList(query url.Values, name string) ([]uuid.UUID, error) {
+	ids := []uuid.UUID{}
+	for _, value := range query[name] {
+		if value == "" {
+			continue
+		}
+		for _, part := range strings.Split(value, ",") {
+			id, err := uuid.Parse(strings.TrimSpace(part))
+			if err != nil {
+				return nil, filterError(name, fmt.Sprintf("%q is not a valid id", part))
+			}
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) > maxFilterAuthors {
+		return nil, filterError(name, fmt.Sprintf("can list at most %d ids", maxFilterAuthors))
+	}
+	return ids, nil
+}
+
+func parseTimeFilter(query url.Values, name string) (sql.NullTime, error) {
+	value := query.Get(name)
+	if value == "" {
+		return sql.NullTime{}, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return sql.NullTime{}, filterError(name, "must be an RFC 3339 timestamp")
+	}
+	return sql.NullTime{Time: t.UTC(), Valid: true}, nil
+}
+
+func parseBoolFilter(query url.Values, name string) (sql.NullBool, error) {
+	value := query.Get(name)
+	if value == "" {
+		return sql.NullBool{}, nil
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return sql.NullBool{}, filterError(name, "must be true or false")
+	}
+	return sql.NullBool{Bool: b, Valid: true}, nil
+}
+
+// parseChirpIDFilter looks up the chirp named by a since_id or max_id
+// parameter. Chirps the viewer can't see are reported as not found.
+func (cfg *apiConfig) parseChirpIDFilter(ctx context.Context, query url.Values, name string, viewerID uuid.UUID) (*database.Chirp, error) {
+	value := query.Get(name)
+	if value == "" {
+		return nil, nil
+	}
+	id, err := uuid.Parse(value)
+	if err != nil {
+		return nil, filterError(name, "is not a valid id")
+	}
+	chirp, err := cfg.db.GetChirp(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, filterError(name, "no such chirp")
+	}
+	if err != nil {
+		return nil, err
+	}
+	visible, err := cfg.chirpVisibleTo(ctx, chirp, viewerID)
+	if err != nil {
+		return nil, err
+	}
+	if !visible {
+		return nil, filterError(name, "no such chirp")
+	}
+	return &chirp, nil
+}
+
+func filterError(param, msg string) error {
+	return &chirpError{http.StatusBadRequest, param + ": " + msg}
+}
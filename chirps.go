@@ -0,0 +1,564 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/mjh1207/chirpy/internal/database"
+	"github.com/mjh1207/chirpy/internal/entities"
+	"github.com/mjh1207/chirpy/internal/entitlements"
+	"github.com/mjh1207/chirpy/internal/moderation"
+	"github.com/mjh1207/chirpy/internal/spam"
+)
+
+// chirpTrashWindow is how long a deleted chirp can be restored by its author
+// before it is purged.
+const chirpTrashWindow = 30 * 24 * time.Hour
+
+// Chirp visibility levels. Followers-only chirps are visible to the author's
+// followers, and mentioned-only chirps to the users mentioned in them.
+const (
+	visibilityPublic    = "public"
+	visibilityFollowers = "followers"
+	visibilityMentioned = "mentioned"
+)
+
+// maxContentWarningLength is the longest content warning, in characters, a
+// chirp can carry.
+const maxContentWarningLength = 100
+
+// chirpError is a problem with a submitted chirp that should be reported
+// back to its author rather than treated as a server error.
+type chirpError struct {
+	status int
+	msg    string
+}
+
+func (e *chirpError) Error() string {
+	return e.msg
+}
+
+// errInvalidMedia is returned by saveChirp when a media ID doesn't belong to
+// the author or is already attached to another chirp.
+var errInvalidMedia = &chirpError{http.StatusBadRequest, "Media not found or already attached"}
+
+// chirpInput is what an author submits to create a chirp, whether by posting
+// it directly or by publishing a draft.
+type chirpInput struct {
+	Body       string
+	UserID     uuid.UUID
+	MediaIDs   []uuid.UUID
+	PublishAt  *time.Time
+	Poll       *pollInput
+	Visibility string
+	// ContentWarning is shown in place of the body until the reader
+	// expands the chirp.
+	ContentWarning string
+	// InReplyTo is the chirp this one replies to, if any.
+	InReplyTo *uuid.UUID
+}
+
+// createChirp validates and moderates a submitted chirp against the
+// author's entitlements, then saves it with qtx. Problems with the
+// submission are returned as a *chirpError.
+func (cfg *apiConfig) createChirp(ctx context.Context, qtx *database.Queries, limits entitlements.Entitlements, input chirpInput) (database.Chirp, error) {
+	if err := validateChirpBody(input.Body, limits); err != nil {
+		return database.Chirp{}, err
+	}
+
+	publishAt := sql.NullTime{}
+	if input.PublishAt != nil {
+		if !input.PublishAt.After(time.Now()) {
+			return database.Chirp{}, &chirpError{http.StatusBadRequest, "publish_at must be in the future"}
+		}
+		publishAt = sql.NullTime{Time: input.PublishAt.UTC(), Valid: true}
+	}
+
+	visibility := input.Visibility
+	switch visibility {
+	case "":
+		visibility = visibilityPublic
+	case visibilityPublic, visibilityFollowers, visibilityMentioned:
+	default:
+		return database.Chirp{}, &chirpError{http.StatusBadRequest, "visibility must be one of public, followers or mentioned"}
+	}
+
+	inReplyTo := uuid.NullUUID{}
+	if input.InReplyTo != nil {
+		parent, err := qtx.GetChirp(ctx, *input.InReplyTo)
+		if errors.Is(err, sql.ErrNoRows) {
+			return database.Chirp{}, &chirpError{http.StatusBadRequest, "in_reply_to_id: no such chirp"}
+		}
+		if err != nil {
+			return database.Chirp{}, err
+		}
+		visible, err := cfg.chirpVisibleTo(ctx, parent, input.UserID)
+		if err != nil {
+			return database.Chirp{}, err
+		}
+		if !visible || parent.PublishAt.Valid || parent.DeletedAt.Valid || parent.HiddenAt.Valid {
+			return database.Chirp{}, &chirpError{http.StatusBadRequest, "in_reply_to_id: no such chirp"}
+		}
+		inReplyTo = uuid.NullUUID{UUID: parent.ID, Valid: true}
+	}
+
+	if len(input.MediaIDs) > limits.MaxChirpMedia {
+		return database.Chirp{}, &chirpError{http.StatusBadRequest, fmt.Sprintf("A chirp can have at most %d media attachments", limits.MaxChirpMedia)}
+	}
+
+	var poll *pollInput
+	if input.Poll != nil {
+		opensAt := time.Now()
+		if input.PublishAt != nil {
+			opensAt = *input.PublishAt
+		}
+		if err := validatePoll(*input.Poll, opensAt); err != nil {
+			return database.Chirp{}, err
+		}
+		poll = &pollInput{ClosesAt: input.Poll.ClosesAt}
+	}
+
+	moderated, err := cfg.moderate(input.Body)
+	if err != nil {
+		return database.Chirp{}, err
+	}
+	flags := moderated.Flags
+	if poll != nil {
+		for _, option := range input.Poll.Options {
+			moderatedOption, err := cfg.moderate(option)
+			if err != nil {
+				return database.Chirp{}, err
+			}
+			poll.Options = append(poll.Options, moderatedOption.Text)
+			flags = append(flags, moderatedOption.Flags...)
+		}
+	}
+
+	contentWarning, contentWarningFlags, err := cfg.moderateContentWarning(input.ContentWarning)
+	if err != nil {
+		return database.Chirp{}, err
+	}
+	flags = append(flags, contentWarningFlags...)
+
+	verdict, err := cfg.scoreChirp(ctx, qtx, input.UserID, uuid.Nil, moderated.Text)
+	if err != nil {
+		return database.Chirp{}, err
+	}
+	if verdict.Decision == spam.RateLimit {
+		return database.Chirp{}, &chirpError{http.StatusTooManyRequests, "This looks like spam, try again later"}
+	}
+
+	chirp, err := saveChirp(ctx, qtx, database.CreateChirpParams{
+		Body:           moderated.Text,
+		UserID:         input.UserID,
+		PublishAt:      publishAt,
+		Visibility:     visibility,
+		ContentWarning: contentWarning,
+		InReplyToID:    inReplyTo,
+	}, input.MediaIDs)
+	if err != nil {
+		return database.Chirp{}, err
+	}
+
+	if poll != nil {
+		if err := savePoll(ctx, qtx, chirp.ID, *poll); err != nil {
+			return database.Chirp{}, err
+		}
+	}
+
+	// Likely spam is posted hidden and left for a moderator to review
+	if verdict.Decision == spam.Moderate {
+		if err := qtx.HideChirp(ctx, chirp.ID); err != nil {
+			return database.Chirp{}, err
+		}
+		flags = append(flags, moderation.Match{
+			Filter: "spam",
+			Action: moderation.Flag,
+			Reason: spamFlagReason(verdict),
+		})
+		chirp, err = qtx.GetChirp(ctx, chirp.ID)
+		if err != nil {
+			return database.Chirp{}, err
+		}
+	}
+
+	if err := saveFlags(ctx, qtx, chirp.ID, flags); err != nil {
+		return database.Chirp{}, err
+	}
+
+	// Scheduled chirps reach timelines when they're published
+	if !chirp.PublishAt.Valid {
+		if err := fanOutChirps(ctx, qtx, []uuid.UUID{chirp.ID}); err != nil {
+			return database.Chirp{}, err
+		}
+	}
+
+	return chirp, nil
+}
+
+// validateChirpBody checks a chirp body against the author's length limit.
+func validateChirpBody(body string, limits entitlements.Entitlements) error {
+	if utf8.RuneCountInString(body) > limits.MaxChirpLength {
+		return &chirpError{http.StatusBadRequest, fmt.Sprintf("Chirp is too long; the limit is %d characters", limits.MaxChirpLength)}
+	}
+	return nil
+}
+
+// moderateContentWarning validates and moderates a content warning submitted
+// by a chirp's author, returning it trimmed of surrounding space.
+func (cfg *apiConfig) moderateContentWarning(contentWarning string) (string, []moderation.Match, error) {
+	contentWarning = strings.TrimSpace(contentWarning)
+	if contentWarning == "" {
+		return "", nil, nil
+	}
+	if len([]rune(contentWarning)) > maxContentWarningLength {
+		return "", nil, &chirpError{http.StatusBadRequest, fmt.Sprintf("content_warning can be at most %d characters", maxContentWarningLength)}
+	}
+	moderated, err := cfg.moderate(contentWarning)
+	if err != nil {
+		return "", nil, err
+	}
+	return moderated.Text, moderated.Flags, nil
+}
+
+// respondWithChirpError reports an error from createChirp, passing a
+// *chirpError through to the client and hiding anything else.
+func respondWithChirpError(w http.ResponseWriter, err error) {
+	var chirpErr *chirpError
+	if errors.As(err, &chirpErr) {
+		respondWithError(w, chirpErr.status, chirpErr.msg, nil)
+		return
+	}
+	respondWithError(w, http.StatusInternalServerError, "Couldn't create new chirp", err)
+}
+
+// saveChirp inserts a chirp together with the hashtags and mentions found in
+// its body, and attaches the given media to it. qtx should be bound to a
+// transaction so a chirp is never stored half-finished.
+func saveChirp(ctx context.Context, qtx *database.Queries, params database.CreateChirpParams, mediaIDs []uuid.UUID) (database.Chirp, error) {
+	chirp, err := qtx.CreateChirp(ctx, params)
+	if err != nil {
+		return database.Chirp{}, err
+	}
+
+	if len(mediaIDs) > 0 {
+		attached, err := qtx.AttachMediaFiles(ctx, database.AttachMediaFilesParams{
+			ChirpID: uuid.NullUUID{UUID: chirp.ID, Valid: true},
+			Ids:     mediaIDs,
+			UserID:  chirp.UserID,
+		})
+		if err != nil {
+			return database.Chirp{}, err
+		}
+		if len(attached) != len(mediaIDs) {
+			return database.Chirp{}, errInvalidMedia
+		}
+	}
+
+	if err := saveHashtags(ctx, qtx, chirp); err != nil {
+		return database.Chirp{}, err
+	}
+
+	if err := saveMentions(ctx, qtx, chirp); err != nil {
+		return database.Chirp{}, err
+	}
+
+	return chirp, nil
+}
+
+// saveHashtags records the hashtags in a chirp body. Tags the chirp already
+// has keep their original timestamp, so editing a chirp doesn't make its
+// hashtags trend again.
+func saveHashtags(ctx context.Context, qtx *database.Queries, chirp database.Chirp) error {
+	for _, hashtag := range entities.ParseHashtags(chirp.Body) {
+		err := qtx.CreateChirpHashtag(ctx, database.CreateChirpHashtagParams{
+			ChirpID: chirp.ID,
+			Tag:     hashtag.Tag,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// saveMentions resolves the @handles in a chirp body to users and records
+// where each one appears. Handles that don't belong to anyone are left as
+// plain text.
+func saveMentions(ctx context.Context, qtx *database.Queries, chirp database.Chirp) error {
+	mentions := entities.ParseMentions(chirp.Body)
+	if len(mentions) == 0 {
+		return nil
+	}
+
+	handles := make([]string, 0, len(mentions))
+	for _, mention := range mentions {
+		handles = append(handles, mention.Handle)
+	}
+	users, err := qtx.GetUsersByHandles(ctx, handles)
+	if err != nil {
+		return err
+	}
+	userIDs := make(map[string]uuid.UUID, len(users))
+	ids := make([]uuid.UUID, 0, len(users))
+	for _, user := range users {
+		userIDs[user.Handle.String] = user.ID
+		ids = append(ids, user.ID)
+	}
+
+	// Users blocked by or blocking the author aren't mentioned
+	blocked, err := blockedUsers(ctx, qtx, chirp.UserID, ids)
+	if err != nil {
+		return err
+	}
+
+	for _, mention := range mentions {
+		userID, ok := userIDs[mention.Handle]
+		if !ok || blocked[userID] {
+			continue
+		}
+		err := qtx.CreateChirpMention(ctx, database.CreateChirpMentionParams{
+			ChirpID:     chirp.ID,
+			UserID:      userID,
+			StartOffset: int32(mention.Start),
+			EndOffset:   int32(mention.End),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// blockedUsers returns which of userIDs have blocked, or been blocked by,
+// userID.
+func blockedUsers(ctx context.Context, qtx *database.Queries, userID uuid.UUID, userIDs []uuid.UUID) (map[uuid.UUID]bool, error) {
+	blockedIDs, err := qtx.GetBlockedUserIDs(ctx, database.GetBlockedUserIDsParams{
+		UserID:  userID,
+		UserIds: userIDs,
+	})
+	if err != nil {
+		return nil, err
+	}
+	blocked := make(map[uuid.UUID]bool, len(blockedIDs))
+	for _, id := range blockedIDs {
+		blocked[id] = true
+	}
+	return blocked, nil
+}
+
+// chirpsResponse converts database chirps into their API representation.
+// When viewerID is set, per-viewer fields such as liked_by_me are filled in.
+func (cfg *apiConfig) chirpsResponse(ctx context.Context, viewerID uuid.UUID, chirps []database.Chirp) ([]Chirp, error) {
+	ids := make([]uuid.UUID, 0, len(chirps))
+	for _, chirp := range chirps {
+		ids = append(ids, chirp.ID)
+	}
+
+	liked := map[uuid.UUID]bool{}
+	if viewerID != uuid.Nil && len(ids) > 0 {
+		likedIDs, err := cfg.db.GetLikedChirpIDs(ctx, database.GetLikedChirpIDsParams{
+			UserID:   viewerID,
+			ChirpIds: ids,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range likedIDs {
+			liked[id] = true
+		}
+	}
+
+	mentions := map[uuid.UUID][]database.ChirpMention{}
+	if len(ids) > 0 {
+		rows, err := cfg.db.GetMentionsForChirps(ctx, ids)
+		if err != nil {
+			return nil, err
+		}
+		for _, mention := range rows {
+			mentions[mention.ChirpID] = append(mentions[mention.ChirpID], mention)
+		}
+	}
+
+	mediaFiles := map[uuid.UUID][]Media{}
+	if len(ids) > 0 {
+		rows, err := cfg.db.GetMediaFilesForChirps(ctx, ids)
+		if err != nil {
+			return nil, err
+		}
+		for _, mediaFile := range rows {
+			mediaFiles[mediaFile.ChirpID.UUID] = append(mediaFiles[mediaFile.ChirpID.UUID], mediaResponse(mediaFile))
+		}
+	}
+
+	polls, err := cfg.pollsResponse(ctx, viewerID, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	// Anonymous readers get content warnings and sensitive media collapsed
+	expandWarnings, showSensitive := false, false
+	if viewerID != uuid.Nil {
+		viewer, err := cfg.db.GetUser(ctx, viewerID)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return nil, err
+		}
+		expandWarnings, showSensitive = viewer.ExpandContentWarnings, viewer.ShowSensitiveMedia
+	}
+
+	response := make([]Chirp, 0, len(chirps))
+	for _, chirp := range chirps {
+		c := Chirp{
+			ID:         chirp.ID,
+			CreatedAt:  chirp.CreatedAt,
+			UpdatedAt:  chirp.UpdatedAt,
+			Body:       chirp.Body,
+			User_Id:    chirp.UserID.String(),
+			LikeCount:  chirp.LikeCount,
+			Visibility: chirp.Visibility,
+			Pinned:     chirp.PinnedAt.Valid,
+			Entities:   chirpEntities(chirp.Body, mentions[chirp.ID]),
+			Media:      mediaFiles[chirp.ID],
+			Poll:       polls[chirp.ID],
+
+			ContentWarning:       chirp.ContentWarning,
+			ContentWarningForced: chirp.ContentWarningForced,
+		}
+		if c.Media == nil {
+			c.Media = []Media{}
+		}
+		c.Collapsed = chirp.ContentWarning != "" && !expandWarnings
+		for _, mediaFile := range c.Media {
+			if mediaFile.Sensitive && !showSensitive {
+				c.Collapsed = true
+			}
+		}
+		if chirp.InReplyToID.Valid {
+			c.InReplyToID = &chirp.InReplyToID.UUID
+		}
+		if chirp.PublishAt.Valid {
+			c.PublishAt = &chirp.PublishAt.Time
+		}
+		if chirp.DeletedAt.Valid {
+			c.DeletedAt = &chirp.DeletedAt.Time
+		}
+		if chirp.HiddenAt.Valid {
+			c.HiddenAt = &chirp.HiddenAt.Time
+		}
+		if viewerID != uuid.Nil {
+			likedByMe := liked[chirp.ID]
+			c.LikedByMe = &likedByMe
+		}
+		response = append(response, c)
+	}
+	return response, nil
+}
+
+// chirpResponse is chirpsResponse for a single chirp.
+func (cfg *apiConfig) chirpResponse(ctx context.Context, viewerID uuid.UUID, chirp database.Chirp) (Chirp, error) {
+	response, err := cfg.chirpsResponse(ctx, viewerID, []database.Chirp{chirp})
+	if err != nil {
+		return Chirp{}, err
+	}
+	return response[0], nil
+}
+
+// chirpEntities builds the entities block for a chirp body. Hashtags and
+// URLs are parsed from the body, while mentions come from the users they were
+// resolved to when the chirp was posted.
+func chirpEntities(body string, mentions []database.ChirpMention) Entities {
+	runes := []rune(body)
+	result := Entities{
+		Hashtags: []HashtagEntity{},
+		Mentions: []MentionEntity{},
+		URLs:     []URLEntity{},
+	}
+
+	for _, hashtag := range entities.ParseHashtags(body) {
+		result.Hashtags = append(result.Hashtags, HashtagEntity{
+			Tag:     hashtag.Tag,
+			Indices: [2]int{hashtag.Start, hashtag.End},
+		})
+	}
+
+	for _, mention := range mentions {
+		start, end := int(mention.StartOffset), int(mention.EndOffset)
+		if start < 0 || end > len(runes) || start >= end {
+			continue
+		}
+		result.Mentions = append(result.Mentions, MentionEntity{
+			Handle:  entities.NormalizeHandle(string(runes[start:end])),
+			UserID:  mention.UserID,
+			Indices: [2]int{start, end},
+		})
+	}
+
+	for _, url := range entities.ParseURLs(body) {
+		result.URLs = append(result.URLs, URLEntity{
+			URL:     url.URL,
+			Indices: [2]int{url.Start, url.End},
+		})
+	}
+
+	return result
+}
+
+// chirpVisibleTo reports whether viewerID may see chirp. Scheduled chirps
+// are only visible to their author, deleted chirps only to moderators,
+// hidden chirps only to their author and moderators, and everything else
+// follows the chirp's visibility level. Callers should
+// respond with 404 rather than 403 so hidden chirps can't be probed for.
+func (cfg *apiConfig) chirpVisibleTo(ctx context.Context, chirp database.Chirp, viewerID uuid.UUID) (bool, error) {
+	if chirp.PublishAt.Valid && chirp.UserID != viewerID {
+		return false, nil
+	}
+	if chirp.DeletedAt.Valid {
+		return cfg.isModerator(ctx, viewerID)
+	}
+	if chirp.HiddenAt.Valid && chirp.UserID != viewerID {
+		return cfg.isModerator(ctx, viewerID)
+	}
+	return cfg.db.CanViewChirp(ctx, database.CanViewChirpParams{
+		ViewerID: viewerID,
+		ID:       chirp.ID,
+	})
+}
+
+// purgeChirp permanently removes a chirp along with its attached media.
+// Authors delete chirps with SoftDeleteChirp; this is for chirps that can no
+// longer be restored.
+func (cfg *apiConfig) purgeChirp(ctx context.Context, chirpID uuid.UUID) error {
+	mediaFiles, err := cfg.db.GetMediaFilesForChirps(ctx, []uuid.UUID{chirpID})
+	if err != nil {
+		return err
+	}
+
+	if err := cfg.db.DeleteChirp(ctx, chirpID); err != nil {
+		return err
+	}
+
+	for _, mediaFile := range mediaFiles {
+		cfg.deleteMediaBlobs(ctx, mediaFile.ID)
+	}
+	return nil
+}
+
+// isModerator reports whether userID belongs to a moderator. Anonymous
+// viewers are never moderators.
+func (cfg *apiConfig) isModerator(ctx context.Context, userID uuid.UUID) (bool, error) {
+	if userID == uuid.Nil {
+		return false, nil
+	}
+	user, err := cfg.db.GetUser(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	return user.IsModerator, nil
+}
@@ -7,11 +7,13 @@ import (
 	"fmt"
 	"net/http"
 	"os"
-	"sort"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/mjh1207/chirpy/internal/analytics"
 	"github.com/mjh1207/chirpy/internal/auth"
 	"github.com/mjh1207/chirpy/internal/database"
+	"github.com/mjh1207/chirpy/internal/entities"
 )
 
 func handlerReadiness(w http.ResponseWriter, req *http.Request) {
@@ -52,6 +54,7 @@ func (cfg *apiConfig) handlerUsers(w http.ResponseWriter, req *http.Request) {
 	type parameters struct {
 		Password string `json:"password"`
 		Email string `json:"email"`
+		Handle string `json:"handle"`
 	}
 	decoder := json.NewDecoder(req.Body)
 	params := parameters{}
@@ -61,6 +64,12 @@ func (cfg *apiConfig) handlerUsers(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
+	handle, err := parseHandle(params.Handle)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Handle must be 1-%d letters, digits or underscores", entities.MaxHandleLength), err)
+		return
+	}
+
 	hashedPassword, err := auth.HashPassword(params.Password)
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Unable to hash password. User not created", err)
@@ -70,7 +79,12 @@ func (cfg *apiConfig) handlerUsers(w http.ResponseWriter, req *http.Request) {
 	user, err := cfg.db.CreateUser(req.Context(), database.CreateUserParams{
 		Email: params.Email,
 		HashedPassword: hashedPassword,
+		Handle: handle,
 	})
+	if isUniqueViolation(err) {
+		respondWithError(w, http.StatusConflict, "Email or handle already in use", err)
+		return
+	}
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Couldn't create new user", err)
 		return
@@ -82,6 +96,11 @@ func (cfg *apiConfig) handlerUsers(w http.ResponseWriter, req *http.Request) {
 		UpdatedAt: user.UpdatedAt,
 		Email: user.Email,
 		IsChirpyRed: user.IsChirpyRed.Bool,
+		Handle: user.Handle.String,
+		FollowerCount: user.FollowerCount,
+		FollowingCount: user.FollowingCount,
+		Protected: user.Protected,
+		DMPolicy: user.DmPolicy,
 	})
 }
 
@@ -89,6 +108,7 @@ func (cfg *apiConfig) handlerUpdateUser(w http.ResponseWriter, req *http.Request
 	type parameters struct {
 		Password string `json:"password"`
 		Email string `json:"email"`
+		Handle string `json:"handle"`
 	}
 
 	token, err := auth.GetBearerToken(req.Header)
@@ -111,6 +131,12 @@ func (cfg *apiConfig) handlerUpdateUser(w http.ResponseWriter, req *http.Request
 		return
 	}
 
+	handle, err := parseHandle(params.Handle)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Handle must be 1-%d letters, digits or underscores", entities.MaxHandleLength), err)
+		return
+	}
+
 	hashedPassword, err := auth.HashPassword(params.Password)
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Unable to hash password", err)
@@ -121,9 +147,15 @@ func (cfg *apiConfig) handlerUpdateUser(w http.ResponseWriter, req *http.Request
 		ID: userID,
 		Email: params.Email,
 		HashedPassword: hashedPassword,
+		Handle: handle,
 	})
+	if isUniqueViolation(err) {
+		respondWithError(w, http.StatusConflict, "Email or handle already in use", err)
+		return
+	}
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Unable to update user record", err)
+		return
 	}
 
 	respondWithJSON(w, http.StatusOK, User{
@@ -132,6 +164,11 @@ func (cfg *apiConfig) handlerUpdateUser(w http.ResponseWriter, req *http.Request
 		UpdatedAt: updatedUser.UpdatedAt,
 		Email: updatedUser.Email,
 		IsChirpyRed: updatedUser.IsChirpyRed.Bool,
+		Handle: updatedUser.Handle.String,
+		FollowerCount: updatedUser.FollowerCount,
+		FollowingCount: updatedUser.FollowingCount,
+		Protected: updatedUser.Protected,
+		DMPolicy: updatedUser.DmPolicy,
 	})
 	
 }
@@ -140,6 +177,12 @@ func (cfg *apiConfig) handlerPostChirps(w http.ResponseWriter, req *http.Request
 	type parameters struct {
 		Body string `json:"body"`
 		User_Id uuid.UUID `json:"user_id"`
+		MediaIDs []uuid.UUID `json:"media_ids"`
+		PublishAt *time.Time `json:"publish_at"`
+		Poll *pollInput `json:"poll"`
+		Visibility string `json:"visibility"`
+		ContentWarning string `json:"content_warning"`
+		InReplyToID *uuid.UUID `json:"in_reply_to_id"`
 	}
 
 	token, err := auth.GetBearerToken(req.Header)
@@ -163,28 +206,65 @@ func (cfg *apiConfig) handlerPostChirps(w http.ResponseWriter, req *http.Request
 	}
 	params.User_Id = userID
 
-	const maxChirpLength = 140
-	if len(params.Body) > maxChirpLength {
-		respondWithError(w, http.StatusBadRequest, "Chirp is too long", nil)
+	limits, err := cfg.entitlementsFor(req.Context(), userID)
+	if errors.Is(err, errAccountSuspended) {
+		respondWithError(w, http.StatusForbidden, "Account suspended", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get entitlements", err)
+		return
+	}
+	if !cfg.allowChirp(userID, limits) {
+		respondWithError(w, http.StatusTooManyRequests, "Too many chirps, try again later", nil)
+		return
+	}
+	// Chirps that fail validation or aren't saved don't count
+	posted := false
+	defer func() {
+		if !posted {
+			cfg.refundChirp(userID)
+		}
+	}()
+
+	tx, err := cfg.conn.BeginTx(req.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't start transaction", err)
 		return
 	}
+	defer tx.Rollback()
 
-	chirp, err := cfg.db.CreateChirp(req.Context(), database.CreateChirpParams{
-		Body: replaceProfanity(params.Body),
+	chirp, err := cfg.createChirp(req.Context(), cfg.db.WithTx(tx), limits, chirpInput{
+		Body: params.Body,
 		UserID: params.User_Id,
+		MediaIDs: params.MediaIDs,
+		PublishAt: params.PublishAt,
+		Poll: params.Poll,
+		Visibility: params.Visibility,
+		ContentWarning: params.ContentWarning,
+		InReplyTo: params.InReplyToID,
 	})
 	if err != nil {
+		respondWithChirpError(w, err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Couldn't create new chirp", err)
 		return
 	}
+	posted = true
+	// Scheduled replies are counted when they're published
+	if chirp.InReplyToID.Valid && !chirp.PublishAt.Valid {
+		cfg.chirpStats.Record(chirp.InReplyToID.UUID, analytics.Reply)
+	}
 
-	respondWithJSON(w, http.StatusCreated, Chirp {
-		ID: chirp.ID,
-		CreatedAt: chirp.CreatedAt,
-		UpdatedAt: chirp.UpdatedAt,
-		Body: chirp.Body,
-		User_Id: chirp.UserID.String(),
-	})
+	response, err := cfg.chirpResponse(req.Context(), userID, chirp)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't build chirp response", err)
+		return
+	}
+	respondWithJSON(w, http.StatusCreated, response)
 
 }
 
@@ -209,7 +289,7 @@ func (cfg *apiConfig) handlerDeleteChirp(w http.ResponseWriter, req *http.Reques
 	}
 
 	chirp, err := cfg.db.GetChirp(req.Context(), chirpID)
-	if err != nil {
+	if err != nil || chirp.DeletedAt.Valid {
 		respondWithError(w, http.StatusNotFound, "Chirp not found", err)
 		return
 	}
@@ -219,7 +299,7 @@ func (cfg *apiConfig) handlerDeleteChirp(w http.ResponseWriter, req *http.Reques
 		return
 	}
 
-	err = cfg.db.DeleteChirp(req.Context(), chirpID)
+	err = cfg.db.SoftDeleteChirp(req.Context(), chirpID)
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Failed to delete chirp", err)
 		return
@@ -229,46 +309,27 @@ func (cfg *apiConfig) handlerDeleteChirp(w http.ResponseWriter, req *http.Reques
 }
 
 func (cfg *apiConfig) handlerGetChirps(w http.ResponseWriter, req *http.Request) {
-	authorParam := req.URL.Query().Get("author_id")
-	sortParam := req.URL.Query().Get("sort")
-	var chirps []database.Chirp
-	var err error
-	if authorParam == "" {
-		chirps, err = cfg.db.GetAllChirps(req.Context())
-		if err != nil {
-			respondWithError(w, http.StatusInternalServerError, "Couldn't get chirps from database", err)
-			return
-		}
-	} else {
-		authorID, err := uuid.Parse(authorParam)
-		if err != nil {
-			respondWithError(w, http.StatusBadRequest, "Not a valid author id", err)
-			return
-		}
-		chirps, err = cfg.db.GetChirpsForUser(req.Context(), authorID)
-		if err != nil {
-			respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Couldn't retrieve Chirps for authorID: %v", authorID), err)
-			return
-		}
+	viewerID, err := cfg.viewerID(req)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized request", err)
+		return
 	}
 
-	chirpsSlice := make([]Chirp, 0, len(chirps))
-	for _, chirp := range chirps {
-		chirpsSlice = append(chirpsSlice, Chirp{
-			ID: chirp.ID,
-			CreatedAt: chirp.CreatedAt,
-			UpdatedAt: chirp.UpdatedAt,
-			Body: chirp.Body,
-			User_Id: chirp.UserID.String(),
-		})
-	}
-	if sortParam == "desc" {
-		sort.Slice(chirpsSlice, func(i, j int) bool {return chirpsSlice[i].CreatedAt.After(chirpsSlice[j].CreatedAt)})
+	filters, err := cfg.parseChirpFilters(req.Context(), req.URL.Query(), viewerID)
+	if err != nil {
+		respondWithChirpError(w, err)
+		return
 	}
-	respondWithJSON(w, http.StatusOK, chirpsSlice)
+	cfg.respondWithFilteredChirps(w, req, viewerID, filters)
 }
 
 func (cfg *apiConfig) handlerGetChirp(w http.ResponseWriter, req *http.Request) {
+	viewerID, err := cfg.viewerID(req)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized request", err)
+		return
+	}
+
 	id := req.PathValue("chirpID")
 	parsedId, err := uuid.Parse(id)
 	if err != nil {
@@ -280,14 +341,19 @@ func (cfg *apiConfig) handlerGetChirp(w http.ResponseWriter, req *http.Request)
 		respondWithError(w, http.StatusNotFound, "Unable to get chirp", err)
 		return
 	}
+	visible, err := cfg.chirpVisibleTo(req.Context(), chirp, viewerID)
+	if err != nil || !visible {
+		respondWithError(w, http.StatusNotFound, "Unable to get chirp", err)
+		return
+	}
 
-	respondWithJSON(w, http.StatusOK, Chirp{
-		ID: chirp.ID,
-		CreatedAt: chirp.CreatedAt,
-		UpdatedAt: chirp.UpdatedAt,
-		Body: chirp.Body,
-		User_Id: chirp.UserID.String(),
-	})
+	response, err := cfg.chirpResponse(req.Context(), viewerID, chirp)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't build chirp response", err)
+		return
+	}
+	cfg.recordView(viewerID, chirp)
+	respondWithJSON(w, http.StatusOK, response)
 }
 
 func (cfg *apiConfig) handlerLogin(w http.ResponseWriter, req *http.Request) {
@@ -322,6 +388,11 @@ func (cfg *apiConfig) handlerLogin(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
+	if user.SuspendedAt.Valid {
+		respondWithError(w, http.StatusForbidden, "Account suspended", nil)
+		return
+	}
+
 	accessToken, err := auth.MakeJWT(user.ID, cfg.jwtSecret)
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Could not create authentication token", err)
@@ -353,6 +424,11 @@ func (cfg *apiConfig) handlerLogin(w http.ResponseWriter, req *http.Request) {
 			UpdatedAt: user.UpdatedAt,
 			Email: user.Email,
 			IsChirpyRed: user.IsChirpyRed.Bool,
+			Handle: user.Handle.String,
+			FollowerCount: user.FollowerCount,
+			FollowingCount: user.FollowingCount,
+			Protected: user.Protected,
+			DMPolicy: user.DmPolicy,
 		},
 		Token: accessToken,
 		RefreshToken: refreshToken,
@@ -378,6 +454,11 @@ func (cfg *apiConfig) handlerRefresh(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
+	if user.SuspendedAt.Valid {
+		respondWithError(w, http.StatusForbidden, "Account suspended", nil)
+		return
+	}
+
 	accessToken, err := auth.MakeJWT(user.ID, cfg.jwtSecret)
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Unable to issue new access token", err)
@@ -432,7 +513,7 @@ func (cfg *apiConfig) handlerWebhooks(w http.ResponseWriter, req *http.Request)
 		return
 	}
 
-	_, err = cfg.db.UpgradeUser(req.Context())
+	_, err = cfg.db.UpgradeUser(req.Context(), params.Data.UserID)
 	if errors.Is(err, sql.ErrNoRows) {
 		respondWithError(w, http.StatusNotFound, "User not found", err)
 		return
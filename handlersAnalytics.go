@@ -0,0 +1,160 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/mjh1207/chirpy/internal/database"
+	"github.com/mjh1207/chirpy/internal/entitlements"
+)
+
+const (
+	// defaultAnalyticsDays is how far back analytics go when since isn't
+	// given, if the caller's tier allows it.
+	defaultAnalyticsDays = 7
+	// analyticsTopChirps is how many chirps the per-chirp breakdown lists.
+	analyticsTopChirps = 20
+)
+
+// Values of the analytics interval parameter.
+const (
+	intervalHour = "hour"
+	intervalDay  = "day"
+)
+
+// handlerGetAnalytics reports impressions, views, likes and replies across
+// the caller's chirps over time. Chirpy Red adds hourly intervals and a
+// breakdown of the caller's top chirps.
+func (cfg *apiConfig) handlerGetAnalytics(w http.ResponseWriter, req *http.Request) {
+	userID, err := cfg.authenticate(req)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized request", err)
+		return
+	}
+
+	user, err := cfg.db.GetUser(req.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get user", err)
+		return
+	}
+	limits := cfg.entitlements.For(entitlements.TierFor(user.IsChirpyRed.Bool))
+
+	query := req.URL.Query()
+	interval := query.Get("interval")
+	var step time.Duration
+	switch interval {
+	case "", intervalDay:
+		interval, step = intervalDay, 24*time.Hour
+	case intervalHour:
+		if !limits.DetailedAnalytics {
+			respondWithError(w, http.StatusForbidden, "Hourly analytics require Chirpy Red", nil)
+			return
+		}
+		step = time.Hour
+	default:
+		respondWithChirpError(w, filterError("interval", fmt.Sprintf("must be %s or %s", intervalHour, intervalDay)))
+		return
+	}
+
+	now := time.Now().UTC()
+	since, err := parseTimeFilter(query, "since")
+	if err != nil {
+		respondWithChirpError(w, err)
+		return
+	}
+	until, err := parseTimeFilter(query, "until")
+	if err != nil {
+		respondWithChirpError(w, err)
+		return
+	}
+	if !until.Valid {
+		until.Time = now
+	}
+	if !since.Valid {
+		since.Time = until.Time.AddDate(0, 0, -min(defaultAnalyticsDays, limits.AnalyticsDays))
+	}
+	since.Time = since.Time.Truncate(step)
+	if !since.Time.Before(until.Time) {
+		respondWithChirpError(w, filterError("until", "must be after since"))
+		return
+	}
+	if since.Time.Before(now.AddDate(0, 0, -limits.AnalyticsDays).Truncate(step)) {
+		respondWithChirpError(w, filterError("since", fmt.Sprintf("can be at most %d days ago", limits.AnalyticsDays)))
+		return
+	}
+
+	rows, err := cfg.db.GetUserStatsSeries(req.Context(), database.GetUserStatsSeriesParams{
+		Unit:   interval,
+		UserID: userID,
+		Since:  since.Time,
+		Until:  until.Time,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get analytics", err)
+		return
+	}
+
+	response := Analytics{
+		Since:    since.Time,
+		Until:    until.Time,
+		Interval: interval,
+	}
+	response.Series, response.Totals = statsSeries(rows, since.Time, until.Time, step)
+
+	if limits.DetailedAnalytics {
+		top, err := cfg.db.GetTopChirpStats(req.Context(), database.GetTopChirpStatsParams{
+			UserID:   userID,
+			Since:    since.Time,
+			Until:    until.Time,
+			RowLimit: analyticsTopChirps,
+		})
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Couldn't get analytics", err)
+			return
+		}
+		response.Chirps = make([]ChirpStatsBreakdown, 0, len(top))
+		for _, row := range top {
+			response.Chirps = append(response.Chirps, ChirpStatsBreakdown{
+				ChirpID: row.ChirpID,
+				ChirpStats: ChirpStats{
+					Impressions: row.Impressions,
+					Views:       row.Views,
+					Likes:       row.Likes,
+					Replies:     row.Replies,
+				},
+			})
+		}
+	}
+
+	respondWithJSON(w, http.StatusOK, response)
+}
+
+// statsSeries lists the stats for every interval of step from since until
+// until, with the totals across them. Intervals with no activity have no
+// row, but are still listed.
+func statsSeries(rows []database.GetUserStatsSeriesRow, since, until time.Time, step time.Duration) ([]StatsBucket, ChirpStats) {
+	// Buckets are compared in UTC, since the driver returns them in a zone
+	// of its own and time.Time map keys only match in the same location
+	byBucket := make(map[time.Time]ChirpStats, len(rows))
+	for _, row := range rows {
+		byBucket[row.Bucket.UTC()] = ChirpStats{
+			Impressions: row.Impressions,
+			Views:       row.Views,
+			Likes:       row.Likes,
+			Replies:     row.Replies,
+		}
+	}
+
+	series := []StatsBucket{}
+	var totals ChirpStats
+	for t := since.UTC(); t.Before(until); t = t.Add(step) {
+		stats := byBucket[t]
+		series = append(series, StatsBucket{Time: t, ChirpStats: stats})
+		totals.Impressions += stats.Impressions
+		totals.Views += stats.Views
+		totals.Likes += stats.Likes
+		totals.Replies += stats.Replies
+	}
+	return series, totals
+}
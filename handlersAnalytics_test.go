@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/mjh1207/chirpy/internal/analytics"
+	"github.com/mjh1207/chirpy/internal/database"
+)
+
+func TestStatsSeries(t *testing.T) {
+	since := time.Date(2025, 4, 10, 0, 0, 0, 0, time.UTC)
+	// lib/pq returns timestamps in a zone of its own rather than time.UTC
+	driverZone := time.FixedZone("", 0)
+	rows := []database.GetUserStatsSeriesRow{
+		{Bucket: since.Add(24 * time.Hour).In(driverZone), Impressions: 5, Likes: 2},
+	}
+
+	series, totals := statsSeries(rows, since, since.Add(72*time.Hour), 24*time.Hour)
+	if len(series) != 3 {
+		t.Fatalf("Expected 3 buckets, got %d", len(series))
+	}
+	if series[0].Impressions != 0 || series[1].Impressions != 5 || series[1].Likes != 2 || series[2].Impressions != 0 {
+		t.Errorf("Unexpected series %+v", series)
+	}
+	if totals.Impressions != 5 || totals.Likes != 2 {
+		t.Errorf("Unexpected totals %+v", totals)
+	}
+}
+
+func TestGetAnalytics(t *testing.T) {
+	cfg := newTestConfig(t)
+	_, token := createTestUser(t, cfg, "author")
+
+	var chirp Chirp
+	rec := doTestRequest(t, "POST /api/chirps", cfg.handlerPostChirps, http.MethodPost, "/api/chirps", token, map[string]string{"body": "Counting"}, &chirp)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("Posting chirp: %d %s", rec.Code, rec.Body)
+	}
+	cfg.chirpStats.Record(chirp.ID, analytics.Impression)
+	cfg.chirpStats.Record(chirp.ID, analytics.Impression)
+	cfg.chirpStats.Record(chirp.ID, analytics.Like)
+	if err := cfg.flushStats(context.Background()); err != nil {
+		t.Fatalf("Couldn't flush stats: %v", err)
+	}
+
+	var response Analytics
+	rec = doTestRequest(t, "GET /api/users/me/analytics", cfg.handlerGetAnalytics, http.MethodGet, "/api/users/me/analytics?interval=day", token, nil, &response)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Getting analytics: %d %s", rec.Code, rec.Body)
+	}
+	if response.Totals.Impressions != 2 || response.Totals.Likes != 1 {
+		t.Errorf("Unexpected totals %+v", response.Totals)
+	}
+	last := response.Series[len(response.Series)-1]
+	if last.Impressions != 2 || last.Likes != 1 {
+		t.Errorf("Today's bucket is %+v, want 2 impressions and 1 like", last)
+	}
+}
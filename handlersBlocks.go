@@ -0,0 +1,247 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mjh1207/chirpy/internal/database"
+	"github.com/mjh1207/chirpy/internal/pagination"
+)
+
+// handlerBlockUser blocks a user for the caller. Any follows or follow
+// requests between the two are removed, and until the block is lifted
+// neither can see the other's chirps, or follow, message, reply to or
+// mention the other.
+func (cfg *apiConfig) handlerBlockUser(w http.ResponseWriter, req *http.Request) {
+	blockerID, blockedID, ok := cfg.relationshipRequest(w, req, "block")
+	if !ok {
+		return
+	}
+
+	tx, err := cfg.conn.BeginTx(req.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't start transaction", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	block, err := qtx.BlockUser(req.Context(), database.BlockUserParams{
+		BlockerID: blockerID,
+		BlockedID: blockedID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't block user", err)
+		return
+	}
+	if err := unfollowUser(req.Context(), qtx, blockerID, blockedID); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't update follows", err)
+		return
+	}
+	if err := unfollowUser(req.Context(), qtx, blockedID, blockerID); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't update follows", err)
+		return
+	}
+	for _, request := range []database.DeleteFollowRequestParams{
+		{RequesterID: blockerID, TargetID: blockedID},
+		{RequesterID: blockedID, TargetID: blockerID},
+	} {
+		if _, err := qtx.DeleteFollowRequest(req.Context(), request); err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Couldn't update follow requests", err)
+			return
+		}
+	}
+
+	blocked, err := qtx.GetUser(req.Context(), blockedID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get user", err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't save block", err)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, Block{
+		User:      publicUserResponse(blocked),
+		BlockedAt: block.CreatedAt,
+	})
+}
+
+// handlerUnblockUser lifts the caller's block on a user. Follows removed by
+// the block aren't restored.
+func (cfg *apiConfig) handlerUnblockUser(w http.ResponseWriter, req *http.Request) {
+	blockerID, blockedID, ok := cfg.relationshipRequest(w, req, "block")
+	if !ok {
+		return
+	}
+
+	err := cfg.db.UnblockUser(req.Context(), database.UnblockUserParams{
+		BlockerID: blockerID,
+		BlockedID: blockedID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't unblock user", err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handlerGetBlocks lists the users the caller has blocked, most recent
+// first.
+func (cfg *apiConfig) handlerGetBlocks(w http.ResponseWriter, req *http.Request) {
+	params, limit, ok := cfg.userListRequest(w, req)
+	if !ok {
+		return
+	}
+
+	rows, err := cfg.db.GetBlocks(req.Context(), database.GetBlocksParams(params))
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get blocked users", err)
+		return
+	}
+
+	page := BlockPage{Users: []Block{}}
+	if len(rows) > limit {
+		rows = rows[:limit]
+		last := rows[len(rows)-1]
+		page.NextCursor = pagination.Cursor{Time: last.BlockedAt, ID: last.User.ID}.String()
+	}
+	for _, row := range rows {
+		page.Users = append(page.Users, Block{User: publicUserResponse(row.User), BlockedAt: row.BlockedAt})
+	}
+	respondWithJSON(w, http.StatusOK, page)
+}
+
+// handlerMuteUser hides a user's chirps from the caller's feeds, either
+// until the mute is lifted or for the duration given in the body. Muting a
+// user again replaces the earlier mute.
+func (cfg *apiConfig) handlerMuteUser(w http.ResponseWriter, req *http.Request) {
+	type parameters struct {
+		Duration string `json:"duration"`
+	}
+
+	muterID, mutedID, ok := cfg.relationshipRequest(w, req, "mute")
+	if !ok {
+		return
+	}
+
+	// The body is optional; an empty one mutes until the mute is lifted
+	params := parameters{}
+	err := json.NewDecoder(req.Body).Decode(&params)
+	if err != nil && !errors.Is(err, io.EOF) {
+		respondWithError(w, http.StatusBadRequest, "Couldn't decode parameters", err)
+		return
+	}
+	expiresAt := sql.NullTime{}
+	if params.Duration != "" {
+		duration, err := time.ParseDuration(params.Duration)
+		if err != nil || duration <= 0 {
+			respondWithError(w, http.StatusBadRequest, "duration must be a positive duration such as \"24h\"", err)
+			return
+		}
+		expiresAt = sql.NullTime{Time: time.Now().UTC().Add(duration), Valid: true}
+	}
+
+	mute, err := cfg.db.MuteUser(req.Context(), database.MuteUserParams{
+		MuterID:   muterID,
+		MutedID:   mutedID,
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't mute user", err)
+		return
+	}
+
+	muted, err := cfg.db.GetUser(req.Context(), mutedID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get user", err)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, muteResponse(muted, mute.CreatedAt, mute.ExpiresAt))
+}
+
+func (cfg *apiConfig) handlerUnmuteUser(w http.ResponseWriter, req *http.Request) {
+	muterID, mutedID, ok := cfg.relationshipRequest(w, req, "mute")
+	if !ok {
+		return
+	}
+
+	err := cfg.db.UnmuteUser(req.Context(), database.UnmuteUserParams{
+		MuterID: muterID,
+		MutedID: mutedID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't unmute user", err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handlerGetMutes lists the caller's active mutes, most recent first.
+func (cfg *apiConfig) handlerGetMutes(w http.ResponseWriter, req *http.Request) {
+	params, limit, ok := cfg.userListRequest(w, req)
+	if !ok {
+		return
+	}
+
+	rows, err := cfg.db.GetMutes(req.Context(), database.GetMutesParams(params))
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get muted users", err)
+		return
+	}
+
+	page := MutePage{Users: []Mute{}}
+	if len(rows) > limit {
+		rows = rows[:limit]
+		last := rows[len(rows)-1]
+		page.NextCursor = pagination.Cursor{Time: last.MutedAt, ID: last.User.ID}.String()
+	}
+	for _, row := range rows {
+		page.Users = append(page.Users, muteResponse(row.User, row.MutedAt, row.ExpiresAt))
+	}
+	respondWithJSON(w, http.StatusOK, page)
+}
+
+// relationshipRequest authenticates a request to block, mute or undo either
+// for the user in the path, who must exist and not be the caller. It writes
+// the error response itself and returns false if anything is wrong.
+func (cfg *apiConfig) relationshipRequest(w http.ResponseWriter, req *http.Request, action string) (uuid.UUID, uuid.UUID, bool) {
+	userID, err := cfg.authenticate(req)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized request", err)
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	otherID, err := uuid.Parse(req.PathValue("userID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Not a valid user id", err)
+		return uuid.Nil, uuid.Nil, false
+	}
+	if otherID == userID {
+		respondWithError(w, http.StatusBadRequest, "You can't "+action+" yourself", nil)
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	if _, err := cfg.db.GetUser(req.Context(), otherID); err != nil {
+		respondWithError(w, http.StatusNotFound, "User not found", err)
+		return uuid.Nil, uuid.Nil, false
+	}
+	return userID, otherID, true
+}
+
+func muteResponse(user database.User, mutedAt time.Time, expiresAt sql.NullTime) Mute {
+	response := Mute{
+		User:    publicUserResponse(user),
+		MutedAt: mutedAt,
+	}
+	if expiresAt.Valid {
+		response.ExpiresAt = &expiresAt.Time
+	}
+	return response
+}
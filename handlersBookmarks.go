@@ -0,0 +1,340 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mjh1207/chirpy/internal/database"
+	"github.com/mjh1207/chirpy/internal/pagination"
+)
+
+// maxBookmarkFolderNameLength is the longest name a bookmark folder can have.
+const maxBookmarkFolderNameLength = 50
+
+// unfiledFolder is the folder_id filter for bookmarks that aren't in a
+// folder.
+const unfiledFolder = "unfiled"
+
+// handlerBookmarkChirp bookmarks a chirp for the caller, or moves an
+// existing bookmark to another folder.
+func (cfg *apiConfig) handlerBookmarkChirp(w http.ResponseWriter, req *http.Request) {
+	type parameters struct {
+		FolderID *uuid.UUID `json:"folder_id"`
+	}
+
+	userID, err := cfg.authenticate(req)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized request", err)
+		return
+	}
+
+	chirpID, err := uuid.Parse(req.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Not a valid chirpID", err)
+		return
+	}
+
+	// The body is optional; an empty one bookmarks the chirp without a folder
+	params := parameters{}
+	err = json.NewDecoder(req.Body).Decode(&params)
+	if err != nil && !errors.Is(err, io.EOF) {
+		respondWithError(w, http.StatusBadRequest, "Couldn't decode parameters", err)
+		return
+	}
+
+	chirp, err := cfg.db.GetChirp(req.Context(), chirpID)
+	if err != nil || chirp.PublishAt.Valid || chirp.DeletedAt.Valid || chirp.HiddenAt.Valid {
+		respondWithError(w, http.StatusNotFound, "Chirp not found", err)
+		return
+	}
+	visible, err := cfg.chirpVisibleTo(req.Context(), chirp, userID)
+	if err != nil || !visible {
+		respondWithError(w, http.StatusNotFound, "Chirp not found", err)
+		return
+	}
+
+	folderID := uuid.NullUUID{}
+	if params.FolderID != nil {
+		_, err := cfg.db.GetBookmarkFolder(req.Context(), database.GetBookmarkFolderParams{
+			ID:     *params.FolderID,
+			UserID: userID,
+		})
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "Bookmark folder not found", err)
+			return
+		}
+		folderID = uuid.NullUUID{UUID: *params.FolderID, Valid: true}
+	}
+
+	bookmark, err := cfg.db.SaveBookmark(req.Context(), database.SaveBookmarkParams{
+		UserID:   userID,
+		ChirpID:  chirpID,
+		FolderID: folderID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't save bookmark", err)
+		return
+	}
+
+	response, err := cfg.chirpResponse(req.Context(), userID, chirp)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't build chirp response", err)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, bookmarkResponse(bookmark.FolderID, bookmark.CreatedAt, &response))
+}
+
+func (cfg *apiConfig) handlerDeleteBookmark(w http.ResponseWriter, req *http.Request) {
+	userID, err := cfg.authenticate(req)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized request", err)
+		return
+	}
+
+	chirpID, err := uuid.Parse(req.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Not a valid chirpID", err)
+		return
+	}
+
+	deleted, err := cfg.db.DeleteBookmark(req.Context(), database.DeleteBookmarkParams{
+		UserID:  userID,
+		ChirpID: chirpID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't delete bookmark", err)
+		return
+	}
+	if deleted == 0 {
+		respondWithError(w, http.StatusNotFound, "Bookmark not found", nil)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handlerGetBookmarks lists the caller's bookmarks, most recently bookmarked
+// first. Bookmarked chirps that are deleted, hidden or no longer visible to
+// the caller are left out.
+func (cfg *apiConfig) handlerGetBookmarks(w http.ResponseWriter, req *http.Request) {
+	userID, err := cfg.authenticate(req)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized request", err)
+		return
+	}
+
+	cursor, limit, err := parsePage(req)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	params := database.GetBookmarksParams{
+		UserID:   userID,
+		PageSize: int32(limit + 1),
+	}
+	if cursor != nil {
+		params.BeforeCreatedAt = sql.NullTime{Time: cursor.Time, Valid: true}
+		params.BeforeChirpID = uuid.NullUUID{UUID: cursor.ID, Valid: true}
+	}
+	switch folderParam := req.URL.Query().Get("folder_id"); folderParam {
+	case "":
+	case unfiledFolder:
+		params.FilterFolder = true
+	default:
+		folderID, err := uuid.Parse(folderParam)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "Not a valid folder_id", err)
+			return
+		}
+		params.FilterFolder = true
+		params.FolderID = uuid.NullUUID{UUID: folderID, Valid: true}
+	}
+
+	rows, err := cfg.db.GetBookmarks(req.Context(), params)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get bookmarks", err)
+		return
+	}
+
+	page := BookmarkPage{Bookmarks: []Bookmark{}}
+	if len(rows) > limit {
+		rows = rows[:limit]
+		last := rows[len(rows)-1]
+		page.NextCursor = pagination.Cursor{Time: last.BookmarkedAt, ID: last.Chirp.ID}.String()
+	}
+
+	chirps := make([]database.Chirp, 0, len(rows))
+	for _, row := range rows {
+		chirps = append(chirps, row.Chirp)
+	}
+	chirpsSlice, err := cfg.chirpsResponse(req.Context(), userID, chirps)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't build chirp response", err)
+		return
+	}
+	cfg.recordImpressions(userID, chirps)
+	for i, row := range rows {
+		page.Bookmarks = append(page.Bookmarks, bookmarkResponse(row.FolderID, row.BookmarkedAt, &chirpsSlice[i]))
+	}
+	respondWithJSON(w, http.StatusOK, page)
+}
+
+func (cfg *apiConfig) handlerGetBookmarkFolders(w http.ResponseWriter, req *http.Request) {
+	userID, err := cfg.authenticate(req)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized request", err)
+		return
+	}
+
+	folders, err := cfg.db.GetBookmarkFolders(req.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get bookmark folders", err)
+		return
+	}
+
+	response := make([]BookmarkFolder, 0, len(folders))
+	for _, folder := range folders {
+		response = append(response, bookmarkFolderResponse(folder))
+	}
+	respondWithJSON(w, http.StatusOK, response)
+}
+
+func (cfg *apiConfig) handlerCreateBookmarkFolder(w http.ResponseWriter, req *http.Request) {
+	userID, name, ok := cfg.bookmarkFolderRequest(w, req)
+	if !ok {
+		return
+	}
+
+	folder, err := cfg.db.CreateBookmarkFolder(req.Context(), database.CreateBookmarkFolderParams{
+		UserID: userID,
+		Name:   name,
+	})
+	if isUniqueViolation(err) {
+		respondWithError(w, http.StatusConflict, "You already have a bookmark folder with that name", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't create bookmark folder", err)
+		return
+	}
+	respondWithJSON(w, http.StatusCreated, bookmarkFolderResponse(folder))
+}
+
+func (cfg *apiConfig) handlerRenameBookmarkFolder(w http.ResponseWriter, req *http.Request) {
+	folderID, err := uuid.Parse(req.PathValue("folderID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Not a valid folderID", err)
+		return
+	}
+
+	userID, name, ok := cfg.bookmarkFolderRequest(w, req)
+	if !ok {
+		return
+	}
+
+	folder, err := cfg.db.RenameBookmarkFolder(req.Context(), database.RenameBookmarkFolderParams{
+		Name:   name,
+		ID:     folderID,
+		UserID: userID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "Bookmark folder not found", err)
+		return
+	}
+	if isUniqueViolation(err) {
+		respondWithError(w, http.StatusConflict, "You already have a bookmark folder with that name", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't rename bookmark folder", err)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, bookmarkFolderResponse(folder))
+}
+
+// handlerDeleteBookmarkFolder deletes one of the caller's folders. The
+// bookmarks in it are kept without a folder.
+func (cfg *apiConfig) handlerDeleteBookmarkFolder(w http.ResponseWriter, req *http.Request) {
+	userID, err := cfg.authenticate(req)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized request", err)
+		return
+	}
+
+	folderID, err := uuid.Parse(req.PathValue("folderID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Not a valid folderID", err)
+		return
+	}
+
+	deleted, err := cfg.db.DeleteBookmarkFolder(req.Context(), database.DeleteBookmarkFolderParams{
+		ID:     folderID,
+		UserID: userID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't delete bookmark folder", err)
+		return
+	}
+	if deleted == 0 {
+		respondWithError(w, http.StatusNotFound, "Bookmark folder not found", nil)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// bookmarkFolderRequest authenticates a request to create or rename a
+// bookmark folder and validates the name in its body. It writes the error
+// response itself and returns false if anything is wrong.
+func (cfg *apiConfig) bookmarkFolderRequest(w http.ResponseWriter, req *http.Request) (uuid.UUID, string, bool) {
+	type parameters struct {
+		Name string `json:"name"`
+	}
+
+	userID, err := cfg.authenticate(req)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized request", err)
+		return uuid.Nil, "", false
+	}
+
+	params := parameters{}
+	if err := json.NewDecoder(req.Body).Decode(&params); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Couldn't decode parameters", err)
+		return uuid.Nil, "", false
+	}
+
+	name := strings.TrimSpace(params.Name)
+	if name == "" || len([]rune(name)) > maxBookmarkFolderNameLength || name == unfiledFolder {
+		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Folder names must be 1-%d characters and not %q", maxBookmarkFolderNameLength, unfiledFolder), nil)
+		return uuid.Nil, "", false
+	}
+	return userID, name, true
+}
+
+func bookmarkResponse(folderID uuid.NullUUID, bookmarkedAt time.Time, chirp *Chirp) Bookmark {
+	response := Bookmark{
+		BookmarkedAt: bookmarkedAt,
+		Chirp:        chirp,
+	}
+	if folderID.Valid {
+		response.FolderID = &folderID.UUID
+	}
+	return response
+}
+
+func bookmarkFolderResponse(folder database.BookmarkFolder) BookmarkFolder {
+	return BookmarkFolder{
+		ID:        folder.ID,
+		CreatedAt: folder.CreatedAt,
+		UpdatedAt: folder.UpdatedAt,
+		Name:      folder.Name,
+	}
+}
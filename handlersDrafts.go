@@ -0,0 +1,299 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mjh1207/chirpy/internal/database"
+)
+
+// maxDraftLength bounds how much text a draft can hold. Drafts may run past
+// the author's chirp length limit while they are being worked on; that limit
+// is only enforced when a draft is published.
+const maxDraftLength = 10000
+
+type draftParameters struct {
+	Body     string      `json:"body"`
+	MediaIDs []uuid.UUID `json:"media_ids"`
+}
+
+// decodeDraft reads and checks the body of a create or update draft request.
+func decodeDraft(w http.ResponseWriter, req *http.Request) (draftParameters, bool) {
+	decoder := json.NewDecoder(req.Body)
+	params := draftParameters{}
+	if err := decoder.Decode(&params); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Couldn't decode parameters", err)
+		return params, false
+	}
+	if len(params.Body) > maxDraftLength {
+		respondWithError(w, http.StatusBadRequest, "Draft is too long", nil)
+		return params, false
+	}
+	if params.MediaIDs == nil {
+		params.MediaIDs = []uuid.UUID{}
+	}
+	return params, true
+}
+
+func (cfg *apiConfig) handlerCreateDraft(w http.ResponseWriter, req *http.Request) {
+	userID, err := cfg.authenticate(req)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized request", err)
+		return
+	}
+
+	params, ok := decodeDraft(w, req)
+	if !ok {
+		return
+	}
+
+	draft, err := cfg.db.CreateDraft(req.Context(), database.CreateDraftParams{
+		UserID:   userID,
+		Body:     params.Body,
+		MediaIds: params.MediaIDs,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't create draft", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, draftResponse(draft))
+}
+
+func (cfg *apiConfig) handlerGetDrafts(w http.ResponseWriter, req *http.Request) {
+	userID, err := cfg.authenticate(req)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized request", err)
+		return
+	}
+
+	drafts, err := cfg.db.GetDraftsForUser(req.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get drafts", err)
+		return
+	}
+
+	response := make([]Draft, 0, len(drafts))
+	for _, draft := range drafts {
+		response = append(response, draftResponse(draft))
+	}
+	respondWithJSON(w, http.StatusOK, response)
+}
+
+func (cfg *apiConfig) handlerGetDraft(w http.ResponseWriter, req *http.Request) {
+	userID, err := cfg.authenticate(req)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized request", err)
+		return
+	}
+
+	draftID, err := uuid.Parse(req.PathValue("draftID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Not a valid draft id", err)
+		return
+	}
+
+	draft, err := cfg.db.GetDraft(req.Context(), database.GetDraftParams{
+		ID:     draftID,
+		UserID: userID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Draft not found", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, draftResponse(draft))
+}
+
+func (cfg *apiConfig) handlerUpdateDraft(w http.ResponseWriter, req *http.Request) {
+	userID, err := cfg.authenticate(req)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized request", err)
+		return
+	}
+
+	draftID, err := uuid.Parse(req.PathValue("draftID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Not a valid draft id", err)
+		return
+	}
+
+	params, ok := decodeDraft(w, req)
+	if !ok {
+		return
+	}
+
+	draft, err := cfg.db.UpdateDraft(req.Context(), database.UpdateDraftParams{
+		Body:     params.Body,
+		MediaIds: params.MediaIDs,
+		ID:       draftID,
+		UserID:   userID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "Draft not found", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't update draft", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, draftResponse(draft))
+}
+
+func (cfg *apiConfig) handlerDeleteDraft(w http.ResponseWriter, req *http.Request) {
+	userID, err := cfg.authenticate(req)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized request", err)
+		return
+	}
+
+	draftID, err := uuid.Parse(req.PathValue("draftID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Not a valid draft id", err)
+		return
+	}
+
+	deleted, err := cfg.db.DeleteDraft(req.Context(), database.DeleteDraftParams{
+		ID:     draftID,
+		UserID: userID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't delete draft", err)
+		return
+	}
+	if deleted == 0 {
+		respondWithError(w, http.StatusNotFound, "Draft not found", nil)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handlerPublishDraft turns a draft into a chirp. The draft is removed in the
+// same transaction that creates the chirp, so a draft is published at most
+// once and is kept if the chirp is rejected.
+func (cfg *apiConfig) handlerPublishDraft(w http.ResponseWriter, req *http.Request) {
+	type parameters struct {
+		PublishAt      *time.Time `json:"publish_at"`
+		Visibility     string     `json:"visibility"`
+		ContentWarning string     `json:"content_warning"`
+	}
+
+	userID, err := cfg.authenticate(req)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized request", err)
+		return
+	}
+
+	draftID, err := uuid.Parse(req.PathValue("draftID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Not a valid draft id", err)
+		return
+	}
+
+	// The body is optional; an empty one publishes immediately
+	params := parameters{}
+	err = json.NewDecoder(req.Body).Decode(&params)
+	if err != nil && !errors.Is(err, io.EOF) {
+		respondWithError(w, http.StatusBadRequest, "Couldn't decode parameters", err)
+		return
+	}
+
+	limits, err := cfg.entitlementsFor(req.Context(), userID)
+	if errors.Is(err, errAccountSuspended) {
+		respondWithError(w, http.StatusForbidden, "Account suspended", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get entitlements", err)
+		return
+	}
+	if !cfg.allowChirp(userID, limits) {
+		respondWithError(w, http.StatusTooManyRequests, "Too many chirps, try again later", nil)
+		return
+	}
+	// Drafts that turn out to be missing or invalid don't count
+	published := false
+	defer func() {
+		if !published {
+			cfg.refundChirp(userID)
+		}
+	}()
+
+	tx, err := cfg.conn.BeginTx(req.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't start transaction", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	draft, err := qtx.GetDraft(req.Context(), database.GetDraftParams{
+		ID:     draftID,
+		UserID: userID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Draft not found", err)
+		return
+	}
+
+	deleted, err := qtx.DeleteDraft(req.Context(), database.DeleteDraftParams{
+		ID:     draftID,
+		UserID: userID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't publish draft", err)
+		return
+	}
+	if deleted == 0 {
+		// Another request published or deleted the draft first
+		respondWithError(w, http.StatusNotFound, "Draft not found", nil)
+		return
+	}
+
+	chirp, err := cfg.createChirp(req.Context(), qtx, limits, chirpInput{
+		Body:           draft.Body,
+		UserID:         userID,
+		MediaIDs:       draft.MediaIds,
+		PublishAt:      params.PublishAt,
+		Visibility:     params.Visibility,
+		ContentWarning: params.ContentWarning,
+	})
+	if err != nil {
+		respondWithChirpError(w, err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't publish draft", err)
+		return
+	}
+	published = true
+
+	response, err := cfg.chirpResponse(req.Context(), userID, chirp)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't build chirp response", err)
+		return
+	}
+	respondWithJSON(w, http.StatusCreated, response)
+}
+
+func draftResponse(draft database.Draft) Draft {
+	mediaIDs := draft.MediaIds
+	if mediaIDs == nil {
+		mediaIDs = []uuid.UUID{}
+	}
+	return Draft{
+		ID:        draft.ID,
+		CreatedAt: draft.CreatedAt,
+		UpdatedAt: draft.UpdatedAt,
+		Body:      draft.Body,
+		MediaIDs:  mediaIDs,
+	}
+}
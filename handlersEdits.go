@@ -0,0 +1,191 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mjh1207/chirpy/internal/database"
+	"github.com/mjh1207/chirpy/internal/entities"
+	"github.com/mjh1207/chirpy/internal/moderation"
+	"github.com/mjh1207/chirpy/internal/spam"
+)
+
+// handlerEditChirp replaces the body of one of the caller's chirps. Published
+// chirps can only be edited within the author's edit window; scheduled chirps
+// can be edited until they publish. Hashtags and mentions are re-parsed from
+// the new body, while media and polls are left as they are. The content
+// warning is only changed when one is given, and not at all once a moderator
+// has forced one onto the chirp. New bodies are scored for spam like new
+// chirps, so a chirp can't be edited into spam after it has been posted.
+func (cfg *apiConfig) handlerEditChirp(w http.ResponseWriter, req *http.Request) {
+	type parameters struct {
+		Body           string  `json:"body"`
+		ContentWarning *string `json:"content_warning"`
+	}
+
+	userID, err := cfg.authenticate(req)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized request", err)
+		return
+	}
+
+	chirpID, err := uuid.Parse(req.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Not a valid chirpID", err)
+		return
+	}
+
+	params := parameters{}
+	if err := json.NewDecoder(req.Body).Decode(&params); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Couldn't decode parameters", err)
+		return
+	}
+
+	limits, err := cfg.entitlementsFor(req.Context(), userID)
+	if errors.Is(err, errAccountSuspended) {
+		respondWithError(w, http.StatusForbidden, "Account suspended", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get entitlements", err)
+		return
+	}
+	if err := validateChirpBody(params.Body, limits); err != nil {
+		respondWithChirpError(w, err)
+		return
+	}
+	moderated, err := cfg.moderate(params.Body)
+	if err != nil {
+		respondWithChirpError(w, err)
+		return
+	}
+	flags := moderated.Flags
+
+	var contentWarning *string
+	if params.ContentWarning != nil {
+		moderatedWarning, warningFlags, err := cfg.moderateContentWarning(*params.ContentWarning)
+		if err != nil {
+			respondWithChirpError(w, err)
+			return
+		}
+		contentWarning = &moderatedWarning
+		flags = append(flags, warningFlags...)
+	}
+
+	tx, err := cfg.conn.BeginTx(req.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't start transaction", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	current, err := qtx.GetChirp(req.Context(), chirpID)
+	if err != nil || current.DeletedAt.Valid {
+		respondWithError(w, http.StatusNotFound, "Chirp not found", err)
+		return
+	}
+	if current.UserID != userID {
+		respondWithError(w, http.StatusForbidden, "You do not have permission to edit this Chirp", nil)
+		return
+	}
+	if current.HiddenAt.Valid {
+		respondWithError(w, http.StatusForbidden, "This Chirp has been hidden by a moderator", nil)
+		return
+	}
+	if !current.PublishAt.Valid && time.Now().UTC().Sub(current.CreatedAt) > time.Duration(limits.EditWindow) {
+		respondWithError(w, http.StatusForbidden, "This Chirp can no longer be edited", nil)
+		return
+	}
+
+	if contentWarning == nil {
+		contentWarning = &current.ContentWarning
+	} else if current.ContentWarningForced && *contentWarning != current.ContentWarning {
+		respondWithError(w, http.StatusForbidden, "This Chirp's content warning was set by a moderator", nil)
+		return
+	}
+
+	verdict, err := cfg.scoreChirp(req.Context(), qtx, userID, chirpID, moderated.Text)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't check chirp for spam", err)
+		return
+	}
+	if verdict.Decision == spam.RateLimit {
+		respondWithError(w, http.StatusTooManyRequests, "This looks like spam, try again later", nil)
+		return
+	}
+
+	chirp, err := qtx.UpdateChirpBody(req.Context(), database.UpdateChirpBodyParams{
+		Body:           moderated.Text,
+		ContentWarning: *contentWarning,
+		ID:             chirpID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't edit chirp", err)
+		return
+	}
+
+	tags := []string{}
+	for _, hashtag := range entities.ParseHashtags(chirp.Body) {
+		tags = append(tags, hashtag.Tag)
+	}
+	err = qtx.DeleteStaleChirpHashtags(req.Context(), database.DeleteStaleChirpHashtagsParams{
+		ChirpID: chirp.ID,
+		Tags:    tags,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't edit chirp", err)
+		return
+	}
+	if err := saveHashtags(req.Context(), qtx, chirp); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't edit chirp", err)
+		return
+	}
+
+	if err := qtx.DeleteChirpMentions(req.Context(), chirp.ID); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't edit chirp", err)
+		return
+	}
+	if err := saveMentions(req.Context(), qtx, chirp); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't edit chirp", err)
+		return
+	}
+
+	// Likely spam is hidden and left for a moderator to review
+	if verdict.Decision == spam.Moderate {
+		if err := qtx.HideChirp(req.Context(), chirp.ID); err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Couldn't edit chirp", err)
+			return
+		}
+		flags = append(flags, moderation.Match{
+			Filter: "spam",
+			Action: moderation.Flag,
+			Reason: spamFlagReason(verdict),
+		})
+		chirp, err = qtx.GetChirp(req.Context(), chirp.ID)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Couldn't edit chirp", err)
+			return
+		}
+	}
+
+	if err := saveFlags(req.Context(), qtx, chirp.ID, flags); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't edit chirp", err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't edit chirp", err)
+		return
+	}
+
+	response, err := cfg.chirpResponse(req.Context(), userID, chirp)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't build chirp response", err)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, response)
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestEditChirpChecksSpam(t *testing.T) {
+	cfg := newTestConfig(t)
+	_, token := createTestUser(t, cfg, "author")
+
+	post := func(body string) Chirp {
+		t.Helper()
+		var chirp Chirp
+		rec := doTestRequest(t, "POST /api/chirps", cfg.handlerPostChirps, http.MethodPost, "/api/chirps", token, map[string]string{"body": body}, &chirp)
+		if rec.Code != http.StatusCreated {
+			t.Fatalf("Posting chirp: %d %s", rec.Code, rec.Body)
+		}
+		return chirp
+	}
+	edit := func(chirp Chirp, body string) int {
+		t.Helper()
+		target := "/api/chirps/" + chirp.ID.String()
+		return doTestRequest(t, "PUT /api/chirps/{chirpID}", cfg.handlerEditChirp, http.MethodPut, target, token, map[string]string{"body": body}, nil).Code
+	}
+
+	const repeated = "Buy cheap watches from my shop today"
+	first := post(repeated)
+	post(repeated)
+	other := post("Lovely weather for a walk")
+
+	// The chirp being edited isn't counted as a duplicate of itself
+	if code := edit(first, repeated); code != http.StatusOK {
+		t.Errorf("Re-saving a chirp got %d, want %d", code, http.StatusOK)
+	}
+	if code := edit(other, repeated); code != http.StatusTooManyRequests {
+		t.Errorf("Editing a chirp into a duplicate got %d, want %d", code, http.StatusTooManyRequests)
+	}
+}
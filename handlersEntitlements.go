@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/mjh1207/chirpy/internal/entitlements"
+)
+
+// errAccountSuspended is returned by entitlementsFor for suspended users,
+// who aren't entitled to post anything.
+var errAccountSuspended = errors.New("account suspended")
+
+// entitlementsFor looks up the tier userID is on and returns its limits.
+func (cfg *apiConfig) entitlementsFor(ctx context.Context, userID uuid.UUID) (entitlements.Entitlements, error) {
+	user, err := cfg.db.GetUser(ctx, userID)
+	if err != nil {
+		return entitlements.Entitlements{}, err
+	}
+	if user.SuspendedAt.Valid {
+		return entitlements.Entitlements{}, errAccountSuspended
+	}
+	return cfg.entitlements.For(entitlements.TierFor(user.IsChirpyRed.Bool)), nil
+}
+
+// allowChirp counts a chirp towards userID's hourly limit and reports whether
+// it can be posted.
+func (cfg *apiConfig) allowChirp(userID uuid.UUID, limits entitlements.Entitlements) bool {
+	return cfg.chirpLimiter.Allow(userID.String(), limits.ChirpsPerHour)
+}
+
+// refundChirp takes back a chirp counted by allowChirp that wasn't posted,
+// so that rejected chirps don't use up the caller's limit.
+func (cfg *apiConfig) refundChirp(userID uuid.UUID) {
+	cfg.chirpLimiter.Refund(userID.String())
+}
+
+func (cfg *apiConfig) handlerGetEntitlements(w http.ResponseWriter, req *http.Request) {
+	userID, err := cfg.authenticate(req)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized request", err)
+		return
+	}
+
+	user, err := cfg.db.GetUser(req.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get user", err)
+		return
+	}
+
+	tier := entitlements.TierFor(user.IsChirpyRed.Bool)
+	respondWithJSON(w, http.StatusOK, Entitlements{
+		Tier:         tier,
+		Entitlements: cfg.entitlements.For(tier),
+	})
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/mjh1207/chirpy/internal/database"
+)
+
+// exportBatchSize is how many chirps are read from the database at a time
+// while streaming an export.
+const exportBatchSize = 500
+
+// handlerExportChirps streams the caller's chirps as JSON Lines, oldest
+// first, in the format accepted by handlerImportChirps. Chirps are read in
+// batches so an export never holds more than one batch in memory. Deleted
+// and hidden chirps are left out.
+func (cfg *apiConfig) handlerExportChirps(w http.ResponseWriter, req *http.Request) {
+	userID, err := cfg.authenticate(req)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized request", err)
+		return
+	}
+
+	params := database.GetChirpsForExportParams{
+		UserID:    userID,
+		BatchSize: exportBatchSize,
+	}
+	encoder := json.NewEncoder(w)
+	flusher, _ := w.(http.Flusher)
+	started := false
+	for {
+		chirps, err := cfg.db.GetChirpsForExport(req.Context(), params)
+		if err != nil {
+			// Once the export has started its status can't be changed, so
+			// the client sees a truncated stream
+			if !started {
+				respondWithError(w, http.StatusInternalServerError, "Couldn't export chirps", err)
+			} else {
+				log.Printf("Couldn't export chirps for %s: %v", userID, err)
+			}
+			return
+		}
+
+		if !started {
+			w.Header().Set("Content-Type", "application/x-ndjson")
+			w.Header().Set("Content-Disposition", `attachment; filename="chirps.jsonl"`)
+			w.WriteHeader(http.StatusOK)
+			started = true
+		}
+
+		for _, chirp := range chirps {
+			if err := encoder.Encode(chirpRecord(chirp)); err != nil {
+				log.Printf("Couldn't export chirps for %s: %v", userID, err)
+				return
+			}
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+
+		if len(chirps) < exportBatchSize {
+			return
+		}
+		last := chirps[len(chirps)-1]
+		params.AfterCreatedAt = sql.NullTime{Time: last.CreatedAt, Valid: true}
+		params.AfterID = uuid.NullUUID{UUID: last.ID, Valid: true}
+	}
+}
+
+func chirpRecord(chirp database.Chirp) ChirpRecord {
+	record := ChirpRecord{
+		ID:             chirp.ID,
+		CreatedAt:      chirp.CreatedAt,
+		UpdatedAt:      chirp.UpdatedAt,
+		UserID:         chirp.UserID,
+		Body:           chirp.Body,
+		Visibility:     chirp.Visibility,
+		ContentWarning: chirp.ContentWarning,
+	}
+	if chirp.PublishAt.Valid {
+		record.PublishAt = &chirp.PublishAt.Time
+	}
+	if chirp.InReplyToID.Valid {
+		record.InReplyToID = &chirp.InReplyToID.UUID
+	}
+	return record
+}
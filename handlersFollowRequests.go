@@ -0,0 +1,219 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/mjh1207/chirpy/internal/database"
+	"github.com/mjh1207/chirpy/internal/pagination"
+)
+
+// handlerUpdatePrivacy changes whether the caller's account is protected
+// and who can message it. Fields left out of the request keep their current
+// values. Chirps from a protected account are only visible to its
+// followers, and new followers have to be approved. Unprotecting an account
+// approves any requests still pending.
+func (cfg *apiConfig) handlerUpdatePrivacy(w http.ResponseWriter, req *http.Request) {
+	type parameters struct {
+		Protected *bool   `json:"protected"`
+		DMPolicy  *string `json:"dm_policy"`
+	}
+
+	userID, err := cfg.authenticate(req)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized request", err)
+		return
+	}
+
+	params := parameters{}
+	if err := json.NewDecoder(req.Body).Decode(&params); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Couldn't decode parameters", err)
+		return
+	}
+	if params.DMPolicy != nil {
+		switch *params.DMPolicy {
+		case dmPolicyEveryone, dmPolicyFollowers, dmPolicyNobody:
+		default:
+			respondWithError(w, http.StatusBadRequest, fmt.Sprintf("dm_policy must be one of %s, %s or %s", dmPolicyEveryone, dmPolicyFollowers, dmPolicyNobody), nil)
+			return
+		}
+	}
+
+	tx, err := cfg.conn.BeginTx(req.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't start transaction", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	user, err := qtx.GetUser(req.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get user", err)
+		return
+	}
+	update := database.UpdateUserPrivacyParams{
+		Protected: user.Protected,
+		DmPolicy:  user.DmPolicy,
+		ID:        userID,
+	}
+	if params.Protected != nil {
+		update.Protected = *params.Protected
+	}
+	if params.DMPolicy != nil {
+		update.DmPolicy = *params.DMPolicy
+	}
+
+	wasProtected := user.Protected
+	user, err = qtx.UpdateUserPrivacy(req.Context(), update)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't update user", err)
+		return
+	}
+
+	if wasProtected && !user.Protected {
+		requesterIDs, err := qtx.DeleteFollowRequestsForTarget(req.Context(), userID)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Couldn't approve follow requests", err)
+			return
+		}
+		for _, requesterID := range requesterIDs {
+			if err := followUser(req.Context(), qtx, requesterID, userID); err != nil {
+				respondWithError(w, http.StatusInternalServerError, "Couldn't approve follow requests", err)
+				return
+			}
+		}
+		// Approving requests changed the caller's follower count
+		user, err = qtx.GetUser(req.Context(), userID)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Couldn't get user", err)
+			return
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't update user", err)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, User{
+		ID:             user.ID,
+		CreatedAt:      user.CreatedAt,
+		UpdatedAt:      user.UpdatedAt,
+		Email:          user.Email,
+		IsChirpyRed:    user.IsChirpyRed.Bool,
+		Handle:         user.Handle.String,
+		FollowerCount:  user.FollowerCount,
+		FollowingCount: user.FollowingCount,
+		Protected:      user.Protected,
+		DMPolicy:       user.DmPolicy,
+	})
+}
+
+// handlerGetFollowRequests lists the users waiting for the caller to
+// approve their follow, most recent first.
+func (cfg *apiConfig) handlerGetFollowRequests(w http.ResponseWriter, req *http.Request) {
+	params, limit, ok := cfg.userListRequest(w, req)
+	if !ok {
+		return
+	}
+
+	rows, err := cfg.db.GetFollowRequests(req.Context(), database.GetFollowRequestsParams(params))
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get follow requests", err)
+		return
+	}
+
+	page := FollowRequestPage{Users: []FollowRequest{}}
+	if len(rows) > limit {
+		rows = rows[:limit]
+		last := rows[len(rows)-1]
+		page.NextCursor = pagination.Cursor{Time: last.RequestedAt, ID: last.User.ID}.String()
+	}
+	for _, row := range rows {
+		page.Users = append(page.Users, FollowRequest{User: publicUserResponse(row.User), RequestedAt: row.RequestedAt})
+	}
+	respondWithJSON(w, http.StatusOK, page)
+}
+
+// handlerApproveFollowRequest makes the requester in the path a follower of
+// the caller.
+func (cfg *apiConfig) handlerApproveFollowRequest(w http.ResponseWriter, req *http.Request) {
+	userID, requesterID, ok := cfg.followRequestRequest(w, req)
+	if !ok {
+		return
+	}
+
+	tx, err := cfg.conn.BeginTx(req.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't start transaction", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	deleted, err := qtx.DeleteFollowRequest(req.Context(), database.DeleteFollowRequestParams{
+		RequesterID: requesterID,
+		TargetID:    userID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't approve follow request", err)
+		return
+	}
+	if deleted == 0 {
+		respondWithError(w, http.StatusNotFound, "Follow request not found", nil)
+		return
+	}
+	if err := followUser(req.Context(), qtx, requesterID, userID); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't update follows", err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't approve follow request", err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handlerRejectFollowRequest drops a follow request without telling the
+// requester, who can ask again.
+func (cfg *apiConfig) handlerRejectFollowRequest(w http.ResponseWriter, req *http.Request) {
+	userID, requesterID, ok := cfg.followRequestRequest(w, req)
+	if !ok {
+		return
+	}
+
+	deleted, err := cfg.db.DeleteFollowRequest(req.Context(), database.DeleteFollowRequestParams{
+		RequesterID: requesterID,
+		TargetID:    userID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't reject follow request", err)
+		return
+	}
+	if deleted == 0 {
+		respondWithError(w, http.StatusNotFound, "Follow request not found", nil)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// followRequestRequest authenticates a request to act on the follow request
+// from the user in the path. It writes the error response itself and
+// returns false if anything is wrong.
+func (cfg *apiConfig) followRequestRequest(w http.ResponseWriter, req *http.Request) (uuid.UUID, uuid.UUID, bool) {
+	userID, err := cfg.authenticate(req)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized request", err)
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	requesterID, err := uuid.Parse(req.PathValue("userID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Not a valid user id", err)
+		return uuid.Nil, uuid.Nil, false
+	}
+	return userID, requesterID, true
+}
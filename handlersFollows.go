@@ -0,0 +1,295 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/mjh1207/chirpy/internal/database"
+	"github.com/mjh1207/chirpy/internal/pagination"
+)
+
+func (cfg *apiConfig) handlerFollowUser(w http.ResponseWriter, req *http.Request) {
+	cfg.setFollow(w, req, true)
+}
+
+func (cfg *apiConfig) handlerUnfollowUser(w http.ResponseWriter, req *http.Request) {
+	cfg.setFollow(w, req, false)
+}
+
+// setFollow makes the caller follow or unfollow a user and keeps both users'
+// follow counts and the caller's timeline in step within the same
+// transaction. Following a protected account files a follow request for the
+// account to approve instead, and unfollowing withdraws any pending request.
+// Following someone already followed, or unfollowing someone who isn't,
+// changes nothing.
+func (cfg *apiConfig) setFollow(w http.ResponseWriter, req *http.Request, follow bool) {
+	followerID, err := cfg.authenticate(req)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized request", err)
+		return
+	}
+
+	followeeID, err := uuid.Parse(req.PathValue("userID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Not a valid user id", err)
+		return
+	}
+	if followeeID == followerID {
+		respondWithError(w, http.StatusBadRequest, "You can't follow yourself", nil)
+		return
+	}
+
+	tx, err := cfg.conn.BeginTx(req.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't start transaction", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	followee, err := qtx.GetUser(req.Context(), followeeID)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "User not found", err)
+		return
+	}
+
+	if follow {
+		blocked, err := qtx.BlockExists(req.Context(), database.BlockExistsParams{
+			UserID:  followerID,
+			OtherID: followeeID,
+		})
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Couldn't check blocks", err)
+			return
+		}
+		if blocked {
+			respondWithError(w, http.StatusForbidden, "You can't follow this user", nil)
+			return
+		}
+	}
+
+	if follow && followee.Protected {
+		following, err := qtx.IsFollowing(req.Context(), database.IsFollowingParams{
+			FollowerID: followerID,
+			FolloweeID: followeeID,
+		})
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Couldn't check follows", err)
+			return
+		}
+		if !following {
+			request, err := qtx.CreateFollowRequest(req.Context(), database.CreateFollowRequestParams{
+				RequesterID: followerID,
+				TargetID:    followeeID,
+			})
+			if err != nil {
+				respondWithError(w, http.StatusInternalServerError, "Couldn't request follow", err)
+				return
+			}
+			if err := tx.Commit(); err != nil {
+				respondWithError(w, http.StatusInternalServerError, "Couldn't save follow request", err)
+				return
+			}
+			respondWithJSON(w, http.StatusAccepted, FollowRequest{
+				User:        publicUserResponse(followee),
+				RequestedAt: request.CreatedAt,
+			})
+			return
+		}
+	}
+
+	if follow {
+		err = followUser(req.Context(), qtx, followerID, followeeID)
+	} else {
+		_, err = qtx.DeleteFollowRequest(req.Context(), database.DeleteFollowRequestParams{
+			RequesterID: followerID,
+			TargetID:    followeeID,
+		})
+		if err == nil {
+			err = unfollowUser(req.Context(), qtx, followerID, followeeID)
+		}
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't update follows", err)
+		return
+	}
+
+	followee, err = qtx.GetUser(req.Context(), followeeID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get user", err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't save follow", err)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, publicUserResponse(followee))
+}
+
+// followUser records a follow, then updates both users' counts and adds the
+// followee's recent chirps to the follower's timeline.
+func followUser(ctx context.Context, qtx *database.Queries, followerID, followeeID uuid.UUID) error {
+	changed, err := qtx.FollowUser(ctx, database.FollowUserParams{
+		FollowerID: followerID,
+		FolloweeID: followeeID,
+	})
+	if err != nil || changed == 0 {
+		return err
+	}
+	err = qtx.IncrementFollowCounts(ctx, database.IncrementFollowCountsParams{
+		FolloweeID: followeeID,
+		FollowerID: followerID,
+	})
+	if err != nil {
+		return err
+	}
+	return qtx.BackfillTimeline(ctx, database.BackfillTimelineParams{
+		UserID:       followerID,
+		AuthorID:     followeeID,
+		MaxFollowers: fanOutMaxFollowers,
+		BackfillSize: timelineBackfillSize,
+	})
+}
+
+// unfollowUser removes a follow, then updates both users' counts and takes
+// the followee's chirps out of the follower's timeline.
+func unfollowUser(ctx context.Context, qtx *database.Queries, followerID, followeeID uuid.UUID) error {
+	changed, err := qtx.UnfollowUser(ctx, database.UnfollowUserParams{
+		FollowerID: followerID,
+		FolloweeID: followeeID,
+	})
+	if err != nil || changed == 0 {
+		return err
+	}
+	err = qtx.DecrementFollowCounts(ctx, database.DecrementFollowCountsParams{
+		FolloweeID: followeeID,
+		FollowerID: followerID,
+	})
+	if err != nil {
+		return err
+	}
+	return qtx.RemoveAuthorFromTimeline(ctx, database.RemoveAuthorFromTimelineParams{
+		UserID:   followerID,
+		AuthorID: followeeID,
+	})
+}
+
+// handlerGetFollowers lists the users following userID, most recent first.
+func (cfg *apiConfig) handlerGetFollowers(w http.ResponseWriter, req *http.Request) {
+	params, limit, ok := cfg.userListRequest(w, req)
+	if !ok {
+		return
+	}
+
+	rows, err := cfg.db.GetFollowers(req.Context(), database.GetFollowersParams(params))
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get followers", err)
+		return
+	}
+
+	page := FollowPage{Users: []Follow{}}
+	if len(rows) > limit {
+		rows = rows[:limit]
+		last := rows[len(rows)-1]
+		page.NextCursor = pagination.Cursor{Time: last.FollowedAt, ID: last.User.ID}.String()
+	}
+	for _, row := range rows {
+		page.Users = append(page.Users, Follow{User: publicUserResponse(row.User), FollowedAt: row.FollowedAt})
+	}
+	respondWithJSON(w, http.StatusOK, page)
+}
+
+// handlerGetFollowing lists the users userID follows, most recent first.
+func (cfg *apiConfig) handlerGetFollowing(w http.ResponseWriter, req *http.Request) {
+	params, limit, ok := cfg.userListRequest(w, req)
+	if !ok {
+		return
+	}
+
+	rows, err := cfg.db.GetFollowing(req.Context(), database.GetFollowingParams(params))
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get followed users", err)
+		return
+	}
+
+	page := FollowPage{Users: []Follow{}}
+	if len(rows) > limit {
+		rows = rows[:limit]
+		last := rows[len(rows)-1]
+		page.NextCursor = pagination.Cursor{Time: last.FollowedAt, ID: last.User.ID}.String()
+	}
+	for _, row := range rows {
+		page.Users = append(page.Users, Follow{User: publicUserResponse(row.User), FollowedAt: row.FollowedAt})
+	}
+	respondWithJSON(w, http.StatusOK, page)
+}
+
+// userListParams are the parameters shared by the queries behind paginated
+// lists of users, such as GetFollowers and GetBlocks.
+type userListParams struct {
+	UserID          uuid.UUID
+	BeforeCreatedAt sql.NullTime
+	BeforeUserID    uuid.NullUUID
+	PageSize        int32
+}
+
+// userListRequest reads the page of a list of users belonging to the user
+// in the path, or to the caller when the path has none. It writes the
+// error response itself and returns false if anything is wrong. One more
+// row than the limit is asked for, to tell whether there is a next page.
+func (cfg *apiConfig) userListRequest(w http.ResponseWriter, req *http.Request) (userListParams, int, bool) {
+	var userID uuid.UUID
+	if value := req.PathValue("userID"); value != "" {
+		id, err := uuid.Parse(value)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "Not a valid user id", err)
+			return userListParams{}, 0, false
+		}
+		if _, err := cfg.db.GetUser(req.Context(), id); err != nil {
+			respondWithError(w, http.StatusNotFound, "User not found", err)
+			return userListParams{}, 0, false
+		}
+		userID = id
+	} else {
+		id, err := cfg.authenticate(req)
+		if err != nil {
+			respondWithError(w, http.StatusUnauthorized, "Unauthorized request", err)
+			return userListParams{}, 0, false
+		}
+		userID = id
+	}
+
+	cursor, limit, err := parsePage(req)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return userListParams{}, 0, false
+	}
+
+	params := userListParams{
+		UserID:   userID,
+		PageSize: int32(limit + 1),
+	}
+	if cursor != nil {
+		params.BeforeCreatedAt = sql.NullTime{Time: cursor.Time, Valid: true}
+		params.BeforeUserID = uuid.NullUUID{UUID: cursor.ID, Valid: true}
+	}
+	return params, limit, true
+}
+
+// publicUserResponse is the view of a user shown to other users, which
+// leaves out their email address.
+func publicUserResponse(user database.User) User {
+	return User{
+		ID:             user.ID,
+		CreatedAt:      user.CreatedAt,
+		UpdatedAt:      user.UpdatedAt,
+		IsChirpyRed:    user.IsChirpyRed.Bool,
+		Handle:         user.Handle.String,
+		FollowerCount:  user.FollowerCount,
+		FollowingCount: user.FollowingCount,
+		Protected:      user.Protected,
+	}
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/mjh1207/chirpy/internal/database"
+	"github.com/mjh1207/chirpy/internal/entities"
+)
+
+func (cfg *apiConfig) handlerGetHashtagChirps(w http.ResponseWriter, req *http.Request) {
+	viewerID, err := cfg.viewerID(req)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized request", err)
+		return
+	}
+
+	tag := entities.NormalizeHashtag(req.PathValue("tag"))
+	if tag == "" {
+		respondWithError(w, http.StatusBadRequest, "Hashtag required", nil)
+		return
+	}
+
+	chirps, err := cfg.db.GetChirpsForHashtag(req.Context(), database.GetChirpsForHashtagParams{
+		Tag:      tag,
+		ViewerID: viewerID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get chirps for hashtag", err)
+		return
+	}
+
+	response, err := cfg.chirpsResponse(req.Context(), viewerID, chirps)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't build chirp response", err)
+		return
+	}
+	cfg.recordImpressions(viewerID, chirps)
+	respondWithJSON(w, http.StatusOK, response)
+}
+
+func (cfg *apiConfig) handlerGetTrending(w http.ResponseWriter, req *http.Request) {
+	tags := cfg.trending.get()
+	if tags == nil {
+		tags = []TrendingTag{}
+	}
+	respondWithJSON(w, http.StatusOK, tags)
+}
@@ -0,0 +1,400 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/mjh1207/chirpy/internal/auth"
+	"github.com/mjh1207/chirpy/internal/entities"
+	"github.com/mjh1207/chirpy/internal/entitlements"
+)
+
+const (
+	// maxImportSize bounds the size of an import request body.
+	maxImportSize = 64 << 20
+	// maxImportLineSize bounds the size of a single line of an import.
+	maxImportLineSize = 1 << 20
+	// maxImportLines bounds how many chirps can be imported at once.
+	maxImportLines = 100_000
+)
+
+// importLine is a chirp read from an import along with the line it came from.
+type importLine struct {
+	line   int
+	record ChirpRecord
+}
+
+// handlerImportChirps bulk-inserts chirps from a JSON Lines body, keeping
+// their original IDs and timestamps. Every line is validated before
+// anything is written, and the chirps are only inserted if all of them are
+// valid. With dry_run=true the import is validated but nothing is written.
+func (cfg *apiConfig) handlerImportChirps(w http.ResponseWriter, req *http.Request) {
+	apiKey, err := auth.GetAPIKey(req.Header)
+	if err != nil || cfg.adminKey == "" || apiKey != cfg.adminKey {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized request", err)
+		return
+	}
+
+	report := ImportReport{Errors: []ImportError{}}
+	if value := req.URL.Query().Get("dry_run"); value != "" {
+		report.DryRun, err = strconv.ParseBool(value)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "dry_run: must be true or false", err)
+			return
+		}
+	}
+	// user_id reassigns every chirp, for moving an account to an
+	// environment where it has a different ID
+	var owner *uuid.UUID
+	if value := req.URL.Query().Get("user_id"); value != "" {
+		id, err := uuid.Parse(value)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "user_id: is not a valid id", err)
+			return
+		}
+		owner = &id
+	}
+
+	req.Body = http.MaxBytesReader(w, req.Body, maxImportSize)
+	lines, err := cfg.readImport(req, owner, &report)
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		respondWithError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("Imports are limited to %d bytes", maxImportSize), err)
+		return
+	}
+	if err != nil {
+		respondWithChirpError(w, err)
+		return
+	}
+	report.Lines = len(lines) + len(report.Errors)
+
+	if err := cfg.checkImportReferences(req.Context(), lines, &report); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't validate import", err)
+		return
+	}
+
+	if len(report.Errors) > 0 {
+		respondWithJSON(w, http.StatusBadRequest, report)
+		return
+	}
+	if report.DryRun {
+		respondWithJSON(w, http.StatusOK, report)
+		return
+	}
+
+	if err := cfg.copyChirps(req.Context(), lines); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't import chirps", err)
+		return
+	}
+	report.Imported = len(lines)
+	respondWithJSON(w, http.StatusOK, report)
+}
+
+// readImport parses and validates each line of an import on its own. Lines
+// with problems are added to the report rather than returned. An error is
+// only returned when the body as a whole can't be read, as a *chirpError if
+// the problem is with the import itself.
+func (cfg *apiConfig) readImport(req *http.Request, owner *uuid.UUID, report *ImportReport) ([]importLine, error) {
+	maxLength := cfg.entitlements.For(entitlements.Red).MaxChirpLength
+	seen := map[uuid.UUID]int{}
+	lines := []importLine{}
+
+	scanner := bufio.NewScanner(req.Body)
+	scanner.Buffer(make([]byte, 0, 64<<10), maxImportLineSize)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		data := bytes.TrimSpace(scanner.Bytes())
+		if len(data) == 0 {
+			continue
+		}
+		if len(lines)+len(report.Errors) >= maxImportLines {
+			return nil, &chirpError{http.StatusBadRequest, fmt.Sprintf("An import can have at most %d chirps", maxImportLines)}
+		}
+
+		record := ChirpRecord{}
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&record); err != nil {
+			report.Errors = append(report.Errors, ImportError{lineNumber, "invalid JSON: " + err.Error()})
+			continue
+		}
+		if owner != nil {
+			record.UserID = *owner
+		}
+
+		if err := validateChirpRecord(&record, maxLength); err != nil {
+			report.Errors = append(report.Errors, ImportError{lineNumber, err.Error()})
+			continue
+		}
+		if first, ok := seen[record.ID]; ok {
+			report.Errors = append(report.Errors, ImportError{lineNumber, fmt.Sprintf("id: duplicates line %d", first)})
+			continue
+		}
+		seen[record.ID] = lineNumber
+		lines = append(lines, importLine{line: lineNumber, record: record})
+	}
+	if errors.Is(scanner.Err(), bufio.ErrTooLong) {
+		return nil, &chirpError{http.StatusBadRequest, fmt.Sprintf("line %d: longer than %d bytes", lineNumber+1, maxImportLineSize)}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return lines, nil
+}
+
+// validateChirpRecord checks the fields of one imported chirp and fills in
+// defaults for the optional ones.
+func validateChirpRecord(record *ChirpRecord, maxLength int) error {
+	if record.ID == uuid.Nil {
+		return errors.New("id: is required")
+	}
+	if record.UserID == uuid.Nil {
+		return errors.New("user_id: is required")
+	}
+	if record.CreatedAt.IsZero() {
+		return errors.New("created_at: is required")
+	}
+	if record.CreatedAt.After(time.Now()) {
+		return errors.New("created_at: can't be in the future")
+	}
+	if record.UpdatedAt.IsZero() {
+		record.UpdatedAt = record.CreatedAt
+	}
+	if record.UpdatedAt.Before(record.CreatedAt) {
+		return errors.New("updated_at: can't be before created_at")
+	}
+	if record.Body == "" {
+		return errors.New("body: is required")
+	}
+	if utf8.RuneCountInString(record.Body) > maxLength {
+		return fmt.Errorf("body: longer than %d characters", maxLength)
+	}
+	switch record.Visibility {
+	case "":
+		record.Visibility = visibilityPublic
+	case visibilityPublic, visibilityFollowers, visibilityMentioned:
+	default:
+		return errors.New("visibility: must be one of public, followers or mentioned")
+	}
+	if len([]rune(record.ContentWarning)) > maxContentWarningLength {
+		return fmt.Errorf("content_warning: longer than %d characters", maxContentWarningLength)
+	}
+	if record.InReplyToID != nil && *record.InReplyToID == record.ID {
+		return errors.New("in_reply_to_id: a chirp can't reply to itself")
+	}
+	return nil
+}
+
+// checkImportReferences reports lines whose IDs are already taken, whose
+// authors don't exist, or which reply to a chirp that is neither in the
+// import nor in the database.
+func (cfg *apiConfig) checkImportReferences(ctx context.Context, lines []importLine, report *ImportReport) error {
+	if len(lines) == 0 {
+		return nil
+	}
+
+	inImport := make(map[uuid.UUID]bool, len(lines))
+	chirpIDs := make([]uuid.UUID, 0, len(lines))
+	userIDs := []uuid.UUID{}
+	seenUsers := map[uuid.UUID]bool{}
+	for _, line := range lines {
+		inImport[line.record.ID] = true
+		chirpIDs = append(chirpIDs, line.record.ID)
+		if !seenUsers[line.record.UserID] {
+			seenUsers[line.record.UserID] = true
+			userIDs = append(userIDs, line.record.UserID)
+		}
+	}
+	replyIDs := []uuid.UUID{}
+	for _, line := range lines {
+		if id := line.record.InReplyToID; id != nil && !inImport[*id] {
+			replyIDs = append(replyIDs, *id)
+		}
+	}
+
+	taken, err := cfg.idSet(ctx, chirpIDs, cfg.db.GetExistingChirpIDs)
+	if err != nil {
+		return err
+	}
+	users, err := cfg.idSet(ctx, userIDs, cfg.db.GetExistingUserIDs)
+	if err != nil {
+		return err
+	}
+	parents, err := cfg.idSet(ctx, replyIDs, cfg.db.GetExistingChirpIDs)
+	if err != nil {
+		return err
+	}
+
+	for _, line := range lines {
+		record := line.record
+		switch {
+		case taken[record.ID]:
+			report.Errors = append(report.Errors, ImportError{line.line, "id: a chirp with this id already exists"})
+		case !users[record.UserID]:
+			report.Errors = append(report.Errors, ImportError{line.line, "user_id: no such user"})
+		case record.InReplyToID != nil && !inImport[*record.InReplyToID] && !parents[*record.InReplyToID]:
+			report.Errors = append(report.Errors, ImportError{line.line, "in_reply_to_id: no such chirp"})
+		}
+	}
+	return nil
+}
+
+// idSet runs one of the GetExisting*IDs queries and returns its result as a
+// set.
+func (cfg *apiConfig) idSet(ctx context.Context, ids []uuid.UUID, query func(context.Context, []uuid.UUID) ([]uuid.UUID, error)) (map[uuid.UUID]bool, error) {
+	set := map[uuid.UUID]bool{}
+	if len(ids) == 0 {
+		return set, nil
+	}
+	found, err := query(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range found {
+		set[id] = true
+	}
+	return set, nil
+}
+
+// copyChirps inserts imported chirps, along with their hashtags and
+// mentions, using COPY in a single transaction. Hashtags keep the chirp's
+// original timestamp so imported chirps don't show up as trending.
+// Published chirps are added to timelines in the same transaction;
+// scheduled ones are added by the scheduler when they're published.
+func (cfg *apiConfig) copyChirps(ctx context.Context, lines []importLine) error {
+	tx, err := cfg.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	chirpRows := make([][]any, 0, len(lines))
+	hashtagRows := [][]any{}
+	handles := []string{}
+	published := []uuid.UUID{}
+	for _, line := range lines {
+		record := line.record
+		publishAt := sql.NullTime{}
+		if record.PublishAt != nil {
+			publishAt = sql.NullTime{Time: record.PublishAt.UTC(), Valid: true}
+		} else {
+			published = append(published, record.ID)
+		}
+		inReplyTo := uuid.NullUUID{}
+		if record.InReplyToID != nil {
+			inReplyTo = uuid.NullUUID{UUID: *record.InReplyToID, Valid: true}
+		}
+		chirpRows = append(chirpRows, []any{
+			record.ID,
+			record.CreatedAt.UTC(),
+			record.UpdatedAt.UTC(),
+			record.Body,
+			record.UserID,
+			publishAt,
+			record.Visibility,
+			record.ContentWarning,
+			inReplyTo,
+		})
+
+		tags := map[string]bool{}
+		for _, hashtag := range entities.ParseHashtags(record.Body) {
+			if !tags[hashtag.Tag] {
+				tags[hashtag.Tag] = true
+				hashtagRows = append(hashtagRows, []any{record.ID, hashtag.Tag, record.CreatedAt.UTC()})
+			}
+		}
+		for _, mention := range entities.ParseMentions(record.Body) {
+			handles = append(handles, mention.Handle)
+		}
+	}
+
+	err = copyRows(ctx, tx, "chirps", []string{
+		"id", "created_at", "updated_at", "body", "user_id", "publish_at", "visibility", "content_warning", "in_reply_to_id",
+	}, chirpRows)
+	if err != nil {
+		return err
+	}
+	if err := copyRows(ctx, tx, "chirp_hashtags", []string{"chirp_id", "tag", "created_at"}, hashtagRows); err != nil {
+		return err
+	}
+
+	// Mentions are resolved against the users of this environment, and
+	// users blocked by or blocking the author are left out, as saveMentions
+	// does for new chirps
+	mentionRows := [][]any{}
+	if len(handles) > 0 {
+		users, err := qtx.GetUsersByHandles(ctx, handles)
+		if err != nil {
+			return err
+		}
+		userIDs := make(map[string]uuid.UUID, len(users))
+		ids := make([]uuid.UUID, 0, len(users))
+		for _, user := range users {
+			userIDs[user.Handle.String] = user.ID
+			ids = append(ids, user.ID)
+		}
+		blockedByAuthor := map[uuid.UUID]map[uuid.UUID]bool{}
+		for _, line := range lines {
+			for _, mention := range entities.ParseMentions(line.record.Body) {
+				userID, ok := userIDs[mention.Handle]
+				if !ok {
+					continue
+				}
+				blocked, ok := blockedByAuthor[line.record.UserID]
+				if !ok {
+					blocked, err = blockedUsers(ctx, qtx, line.record.UserID, ids)
+					if err != nil {
+						return err
+					}
+					blockedByAuthor[line.record.UserID] = blocked
+				}
+				if !blocked[userID] {
+					mentionRows = append(mentionRows, []any{line.record.ID, userID, mention.Start, mention.End})
+				}
+			}
+		}
+	}
+	if err := copyRows(ctx, tx, "chirp_mentions", []string{"chirp_id", "user_id", "start_offset", "end_offset"}, mentionRows); err != nil {
+		return err
+	}
+
+	if len(published) > 0 {
+		if err := fanOutChirps(ctx, qtx, published); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// copyRows bulk-inserts rows into table with COPY.
+func copyRows(ctx context.Context, tx *sql.Tx, table string, columns []string, rows [][]any) error {
+	if len(rows) == 0 {
+		return nil
+	}
+	stmt, err := tx.PrepareContext(ctx, pq.CopyIn(table, columns...))
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	for _, row := range rows {
+		if _, err := stmt.ExecContext(ctx, row...); err != nil {
+			return err
+		}
+	}
+	_, err = stmt.ExecContext(ctx)
+	return err
+}
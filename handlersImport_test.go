@@ -0,0 +1,103 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mjh1207/chirpy/internal/database"
+)
+
+// importTestChirps runs records through handlerImportChirps and fails the
+// test unless they are all imported.
+func importTestChirps(t *testing.T, cfg *apiConfig, records ...ChirpRecord) {
+	t.Helper()
+	cfg.adminKey = "test-admin-key"
+
+	var body bytes.Buffer
+	for _, record := range records {
+		if err := json.NewEncoder(&body).Encode(record); err != nil {
+			t.Fatalf("Couldn't encode record: %v", err)
+		}
+	}
+	req := httptest.NewRequest(http.MethodPost, "/api/admin/import", &body)
+	req.Header.Set("Authorization", "ApiKey "+cfg.adminKey)
+	rec := httptest.NewRecorder()
+	cfg.handlerImportChirps(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Importing chirps: %d %s", rec.Code, rec.Body)
+	}
+}
+
+func TestImportFansOutToFollowers(t *testing.T) {
+	cfg := newTestConfig(t)
+	author, _ := createTestUser(t, cfg, "author")
+	follower, followerToken := createTestUser(t, cfg, "follower")
+	if err := followUser(context.Background(), cfg.db, follower.ID, author.ID); err != nil {
+		t.Fatalf("Couldn't follow: %v", err)
+	}
+
+	createdAt := time.Now().UTC().Add(-time.Hour)
+	record := ChirpRecord{
+		ID:        uuid.New(),
+		CreatedAt: createdAt,
+		UpdatedAt: createdAt,
+		UserID:    author.ID,
+		Body:      "Imported from elsewhere",
+	}
+	importTestChirps(t, cfg, record)
+
+	var page TimelinePage
+	rec := doTestRequest(t, "GET /api/timeline/home", cfg.handlerGetHomeTimeline, http.MethodGet, "/api/timeline/home", followerToken, nil, &page)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Getting timeline: %d %s", rec.Code, rec.Body)
+	}
+	if len(page.Chirps) != 1 || page.Chirps[0].ID != record.ID {
+		t.Errorf("Expected the imported chirp on the follower's timeline, got %+v", page.Chirps)
+	}
+}
+
+func TestImportSkipsBlockedMentions(t *testing.T) {
+	cfg := newTestConfig(t)
+	author, _ := createTestUser(t, cfg, "author")
+	blocker, _ := createTestUser(t, cfg, "blocker")
+	friend, _ := createTestUser(t, cfg, "friend")
+	_, err := cfg.db.BlockUser(context.Background(), database.BlockUserParams{
+		BlockerID: blocker.ID,
+		BlockedID: author.ID,
+	})
+	if err != nil {
+		t.Fatalf("Couldn't block: %v", err)
+	}
+
+	createdAt := time.Now().UTC().Add(-time.Hour)
+	importTestChirps(t, cfg, ChirpRecord{
+		ID:        uuid.New(),
+		CreatedAt: createdAt,
+		UpdatedAt: createdAt,
+		UserID:    author.ID,
+		Body:      "Hello @blocker and @friend",
+	})
+
+	for _, tt := range []struct {
+		user database.User
+		want int
+	}{
+		{blocker, 0},
+		{friend, 1},
+	} {
+		var count int
+		err := cfg.conn.QueryRow("SELECT COUNT(*) FROM chirp_mentions WHERE user_id = $1", tt.user.ID).Scan(&count)
+		if err != nil {
+			t.Fatalf("Couldn't count mentions: %v", err)
+		}
+		if count != tt.want {
+			t.Errorf("%s has %d mentions, want %d", tt.user.Handle.String, count, tt.want)
+		}
+	}
+}
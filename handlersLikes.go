@@ -0,0 +1,138 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/mjh1207/chirpy/internal/analytics"
+	"github.com/mjh1207/chirpy/internal/database"
+)
+
+func (cfg *apiConfig) handlerLikeChirp(w http.ResponseWriter, req *http.Request) {
+	cfg.setLike(w, req, true)
+}
+
+func (cfg *apiConfig) handlerUnlikeChirp(w http.ResponseWriter, req *http.Request) {
+	cfg.setLike(w, req, false)
+}
+
+// setLike records or removes the caller's like on a chirp and keeps the
+// chirp's like_count in step within the same transaction.
+func (cfg *apiConfig) setLike(w http.ResponseWriter, req *http.Request, like bool) {
+	userID, err := cfg.authenticate(req)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized request", err)
+		return
+	}
+
+	chirpID, err := uuid.Parse(req.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Not a valid chirpID", err)
+		return
+	}
+
+	current, err := cfg.db.GetChirp(req.Context(), chirpID)
+	if err != nil || current.PublishAt.Valid || current.DeletedAt.Valid || current.HiddenAt.Valid {
+		respondWithError(w, http.StatusNotFound, "Chirp not found", err)
+		return
+	}
+	visible, err := cfg.chirpVisibleTo(req.Context(), current, userID)
+	if err != nil || !visible {
+		respondWithError(w, http.StatusNotFound, "Chirp not found", err)
+		return
+	}
+
+	tx, err := cfg.conn.BeginTx(req.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't start transaction", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	liked := false
+	var changed int64
+	if like {
+		changed, err = qtx.LikeChirp(req.Context(), database.LikeChirpParams{
+			UserID:  userID,
+			ChirpID: chirpID,
+		})
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Couldn't like chirp", err)
+			return
+		}
+		if changed > 0 {
+			liked = true
+			err = qtx.IncrementLikeCount(req.Context(), chirpID)
+		}
+	} else {
+		changed, err = qtx.UnlikeChirp(req.Context(), database.UnlikeChirpParams{
+			UserID:  userID,
+			ChirpID: chirpID,
+		})
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Couldn't unlike chirp", err)
+			return
+		}
+		if changed > 0 {
+			err = qtx.DecrementLikeCount(req.Context(), chirpID)
+		}
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't update like count", err)
+		return
+	}
+
+	chirp, err := qtx.GetChirp(req.Context(), chirpID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get chirp", err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't save like", err)
+		return
+	}
+	// Likes count when they're made; unliking later doesn't take them back
+	if liked && chirp.UserID != userID {
+		cfg.chirpStats.Record(chirpID, analytics.Like)
+	}
+
+	response, err := cfg.chirpResponse(req.Context(), userID, chirp)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't build chirp response", err)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, response)
+}
+
+func (cfg *apiConfig) handlerGetUserLikes(w http.ResponseWriter, req *http.Request) {
+	viewerID, err := cfg.viewerID(req)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized request", err)
+		return
+	}
+
+	userID, err := uuid.Parse(req.PathValue("userID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Not a valid user id", err)
+		return
+	}
+
+	chirps, err := cfg.db.GetLikedChirpsForUser(req.Context(), database.GetLikedChirpsForUserParams{
+		UserID:   userID,
+		ViewerID: viewerID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get liked chirps", err)
+		return
+	}
+
+	response, err := cfg.chirpsResponse(req.Context(), viewerID, chirps)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't build chirp response", err)
+		return
+	}
+	cfg.recordImpressions(viewerID, chirps)
+	respondWithJSON(w, http.StatusOK, response)
+}
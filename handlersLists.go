@@ -0,0 +1,552 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/mjh1207/chirpy/internal/database"
+	"github.com/mjh1207/chirpy/internal/pagination"
+)
+
+const (
+	// maxListNameLength is the longest name a list can have.
+	maxListNameLength = 25
+	// maxListMembers is the most accounts a list can hold.
+	maxListMembers = 5000
+)
+
+// List visibility levels. Private lists, their members and their timelines
+// are only visible to their owner.
+const (
+	listPublic  = "public"
+	listPrivate = "private"
+)
+
+func (cfg *apiConfig) handlerCreateList(w http.ResponseWriter, req *http.Request) {
+	userID, name, visibility, ok := cfg.listParamsRequest(w, req)
+	if !ok {
+		return
+	}
+	if visibility == "" {
+		visibility = listPublic
+	}
+
+	list, err := cfg.db.CreateList(req.Context(), database.CreateListParams{
+		OwnerID:    userID,
+		Name:       name,
+		Visibility: visibility,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't create list", err)
+		return
+	}
+	respondWithJSON(w, http.StatusCreated, listResponse(list))
+}
+
+func (cfg *apiConfig) handlerGetList(w http.ResponseWriter, req *http.Request) {
+	viewerID, err := cfg.viewerID(req)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized request", err)
+		return
+	}
+
+	list, ok := cfg.listRequest(w, req, viewerID)
+	if !ok {
+		return
+	}
+	respondWithJSON(w, http.StatusOK, listResponse(list))
+}
+
+// handlerUpdateList renames one of the caller's lists. Its visibility is
+// kept unless the request gives a new one.
+func (cfg *apiConfig) handlerUpdateList(w http.ResponseWriter, req *http.Request) {
+	userID, name, visibility, ok := cfg.listParamsRequest(w, req)
+	if !ok {
+		return
+	}
+
+	list, ok := cfg.ownedListRequest(w, req, userID)
+	if !ok {
+		return
+	}
+	if visibility == "" {
+		visibility = list.Visibility
+	}
+
+	list, err := cfg.db.UpdateList(req.Context(), database.UpdateListParams{
+		Name:       name,
+		Visibility: visibility,
+		ID:         list.ID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't update list", err)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, listResponse(list))
+}
+
+func (cfg *apiConfig) handlerDeleteList(w http.ResponseWriter, req *http.Request) {
+	userID, err := cfg.authenticate(req)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized request", err)
+		return
+	}
+
+	list, ok := cfg.ownedListRequest(w, req, userID)
+	if !ok {
+		return
+	}
+
+	if err := cfg.db.DeleteList(req.Context(), list.ID); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't delete list", err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handlerGetUserLists lists the lists a user owns, newest first. Private
+// lists are only included for their owner.
+func (cfg *apiConfig) handlerGetUserLists(w http.ResponseWriter, req *http.Request) {
+	viewerID, err := cfg.viewerID(req)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized request", err)
+		return
+	}
+
+	ownerID, err := uuid.Parse(req.PathValue("userID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Not a valid user id", err)
+		return
+	}
+	if _, err := cfg.db.GetUser(req.Context(), ownerID); err != nil {
+		respondWithError(w, http.StatusNotFound, "User not found", err)
+		return
+	}
+
+	cursor, limit, err := parsePage(req)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	params := database.GetUserListsParams{
+		OwnerID:        ownerID,
+		IncludePrivate: ownerID == viewerID,
+		PageSize:       int32(limit + 1),
+	}
+	if cursor != nil {
+		params.BeforeCreatedAt = sql.NullTime{Time: cursor.Time, Valid: true}
+		params.BeforeListID = uuid.NullUUID{UUID: cursor.ID, Valid: true}
+	}
+
+	lists, err := cfg.db.GetUserLists(req.Context(), params)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get lists", err)
+		return
+	}
+
+	page := ListPage{Lists: []List{}}
+	if len(lists) > limit {
+		lists = lists[:limit]
+		last := lists[len(lists)-1]
+		page.NextCursor = pagination.Cursor{Time: last.CreatedAt, ID: last.ID}.String()
+	}
+	for _, list := range lists {
+		page.Lists = append(page.Lists, listResponse(list))
+	}
+	respondWithJSON(w, http.StatusOK, page)
+}
+
+// handlerGetSubscribedLists lists the public lists the caller subscribes
+// to, most recently subscribed first.
+func (cfg *apiConfig) handlerGetSubscribedLists(w http.ResponseWriter, req *http.Request) {
+	userID, err := cfg.authenticate(req)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized request", err)
+		return
+	}
+
+	cursor, limit, err := parsePage(req)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	params := database.GetSubscribedListsParams{
+		UserID:   userID,
+		PageSize: int32(limit + 1),
+	}
+	if cursor != nil {
+		params.BeforeCreatedAt = sql.NullTime{Time: cursor.Time, Valid: true}
+		params.BeforeListID = uuid.NullUUID{UUID: cursor.ID, Valid: true}
+	}
+
+	rows, err := cfg.db.GetSubscribedLists(req.Context(), params)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get lists", err)
+		return
+	}
+
+	page := ListPage{Lists: []List{}}
+	if len(rows) > limit {
+		rows = rows[:limit]
+		last := rows[len(rows)-1]
+		page.NextCursor = pagination.Cursor{Time: last.SubscribedAt, ID: last.List.ID}.String()
+	}
+	for _, row := range rows {
+		page.Lists = append(page.Lists, listResponse(row.List))
+	}
+	respondWithJSON(w, http.StatusOK, page)
+}
+
+// handlerGetListMembers lists the accounts in a list, most recently added
+// first.
+func (cfg *apiConfig) handlerGetListMembers(w http.ResponseWriter, req *http.Request) {
+	viewerID, err := cfg.viewerID(req)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized request", err)
+		return
+	}
+
+	list, ok := cfg.listRequest(w, req, viewerID)
+	if !ok {
+		return
+	}
+
+	cursor, limit, err := parsePage(req)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	params := database.GetListMembersParams{
+		ListID:   list.ID,
+		PageSize: int32(limit + 1),
+	}
+	if cursor != nil {
+		params.BeforeCreatedAt = sql.NullTime{Time: cursor.Time, Valid: true}
+		params.BeforeUserID = uuid.NullUUID{UUID: cursor.ID, Valid: true}
+	}
+
+	rows, err := cfg.db.GetListMembers(req.Context(), params)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get list members", err)
+		return
+	}
+
+	page := ListMemberPage{Users: []ListMember{}}
+	if len(rows) > limit {
+		rows = rows[:limit]
+		last := rows[len(rows)-1]
+		page.NextCursor = pagination.Cursor{Time: last.AddedAt, ID: last.User.ID}.String()
+	}
+	for _, row := range rows {
+		page.Users = append(page.Users, ListMember{User: publicUserResponse(row.User), AddedAt: row.AddedAt})
+	}
+	respondWithJSON(w, http.StatusOK, page)
+}
+
+func (cfg *apiConfig) handlerAddListMember(w http.ResponseWriter, req *http.Request) {
+	cfg.setListMember(w, req, true)
+}
+
+func (cfg *apiConfig) handlerRemoveListMember(w http.ResponseWriter, req *http.Request) {
+	cfg.setListMember(w, req, false)
+}
+
+// setListMember adds an account to one of the caller's lists or takes it
+// out, keeping the list's member count in step. Adding a member twice, or
+// removing one who isn't there, changes nothing.
+func (cfg *apiConfig) setListMember(w http.ResponseWriter, req *http.Request, add bool) {
+	userID, err := cfg.authenticate(req)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized request", err)
+		return
+	}
+
+	list, ok := cfg.ownedListRequest(w, req, userID)
+	if !ok {
+		return
+	}
+
+	memberID, err := uuid.Parse(req.PathValue("userID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Not a valid user id", err)
+		return
+	}
+
+	tx, err := cfg.conn.BeginTx(req.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't start transaction", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	var changed int64
+	delta := int32(-1)
+	if add {
+		delta = 1
+		if _, err := qtx.GetUser(req.Context(), memberID); err != nil {
+			respondWithError(w, http.StatusNotFound, "User not found", err)
+			return
+		}
+		var blocked bool
+		blocked, err = qtx.BlockExists(req.Context(), database.BlockExistsParams{
+			UserID:  userID,
+			OtherID: memberID,
+		})
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Couldn't check blocks", err)
+			return
+		}
+		if blocked {
+			respondWithError(w, http.StatusForbidden, "You can't add this user to a list", nil)
+			return
+		}
+		if list.MemberCount >= maxListMembers {
+			respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Lists can have at most %d members", maxListMembers), nil)
+			return
+		}
+		changed, err = qtx.AddListMember(req.Context(), database.AddListMemberParams{
+			ListID: list.ID,
+			UserID: memberID,
+		})
+	} else {
+		changed, err = qtx.RemoveListMember(req.Context(), database.RemoveListMemberParams{
+			ListID: list.ID,
+			UserID: memberID,
+		})
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't update list members", err)
+		return
+	}
+	if changed > 0 {
+		err = qtx.UpdateListMemberCount(req.Context(), database.UpdateListMemberCountParams{
+			Delta: delta,
+			ID:    list.ID,
+		})
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Couldn't update list members", err)
+			return
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't update list members", err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handlerSubscribeList(w http.ResponseWriter, req *http.Request) {
+	cfg.setListSubscription(w, req, true)
+}
+
+func (cfg *apiConfig) handlerUnsubscribeList(w http.ResponseWriter, req *http.Request) {
+	cfg.setListSubscription(w, req, false)
+}
+
+// setListSubscription subscribes the caller to a public list, or
+// unsubscribes them, keeping the list's subscriber count in step. Lists
+// made private can still be unsubscribed from.
+func (cfg *apiConfig) setListSubscription(w http.ResponseWriter, req *http.Request, subscribe bool) {
+	userID, err := cfg.authenticate(req)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized request", err)
+		return
+	}
+
+	listID, err := uuid.Parse(req.PathValue("listID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Not a valid list id", err)
+		return
+	}
+
+	tx, err := cfg.conn.BeginTx(req.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't start transaction", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	list, err := qtx.GetList(req.Context(), listID)
+	if err != nil || (subscribe && !listVisibleTo(list, userID)) {
+		respondWithError(w, http.StatusNotFound, "List not found", err)
+		return
+	}
+
+	var changed int64
+	delta := int32(-1)
+	if subscribe {
+		delta = 1
+		if list.OwnerID == userID {
+			respondWithError(w, http.StatusBadRequest, "You can't subscribe to your own list", nil)
+			return
+		}
+		var blocked bool
+		blocked, err = qtx.BlockExists(req.Context(), database.BlockExistsParams{
+			UserID:  userID,
+			OtherID: list.OwnerID,
+		})
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Couldn't check blocks", err)
+			return
+		}
+		if blocked {
+			respondWithError(w, http.StatusForbidden, "You can't subscribe to this list", nil)
+			return
+		}
+		changed, err = qtx.SubscribeToList(req.Context(), database.SubscribeToListParams{
+			ListID: list.ID,
+			UserID: userID,
+		})
+	} else {
+		changed, err = qtx.UnsubscribeFromList(req.Context(), database.UnsubscribeFromListParams{
+			ListID: list.ID,
+			UserID: userID,
+		})
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't update subscription", err)
+		return
+	}
+	if changed > 0 {
+		err = qtx.UpdateListSubscriberCount(req.Context(), database.UpdateListSubscriberCountParams{
+			Delta: delta,
+			ID:    list.ID,
+		})
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Couldn't update subscription", err)
+			return
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't update subscription", err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handlerGetListTimeline lists chirps from a list's members. It takes the
+// same filters, and is sorted the same way, as GET /api/chirps.
+func (cfg *apiConfig) handlerGetListTimeline(w http.ResponseWriter, req *http.Request) {
+	viewerID, err := cfg.viewerID(req)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized request", err)
+		return
+	}
+
+	list, ok := cfg.listRequest(w, req, viewerID)
+	if !ok {
+		return
+	}
+
+	filters, err := cfg.parseChirpFilters(req.Context(), req.URL.Query(), viewerID)
+	if err != nil {
+		respondWithChirpError(w, err)
+		return
+	}
+	filters.ListID = uuid.NullUUID{UUID: list.ID, Valid: true}
+	// Pinned chirps only lead an author's own timeline
+	filters.PinnedFirst = false
+
+	cfg.respondWithFilteredChirps(w, req, viewerID, filters)
+}
+
+// listRequest looks up the list in the path. Lists the viewer can't see are
+// reported as not found. It writes the error response itself and returns
+// false if anything is wrong.
+func (cfg *apiConfig) listRequest(w http.ResponseWriter, req *http.Request, viewerID uuid.UUID) (database.List, bool) {
+	listID, err := uuid.Parse(req.PathValue("listID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Not a valid list id", err)
+		return database.List{}, false
+	}
+
+	list, err := cfg.db.GetList(req.Context(), listID)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && !listVisibleTo(list, viewerID)) {
+		respondWithError(w, http.StatusNotFound, "List not found", err)
+		return database.List{}, false
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get list", err)
+		return database.List{}, false
+	}
+	return list, true
+}
+
+// ownedListRequest looks up the list in the path for a change by userID,
+// who must own it.
+func (cfg *apiConfig) ownedListRequest(w http.ResponseWriter, req *http.Request, userID uuid.UUID) (database.List, bool) {
+	list, ok := cfg.listRequest(w, req, userID)
+	if !ok {
+		return database.List{}, false
+	}
+	if list.OwnerID != userID {
+		respondWithError(w, http.StatusForbidden, "You can't change this list", nil)
+		return database.List{}, false
+	}
+	return list, true
+}
+
+// listParamsRequest authenticates a request to create or update a list and
+// validates the name and visibility in its body. The visibility is empty
+// when the body leaves it out. It writes the error response itself and
+// returns false if anything is wrong.
+func (cfg *apiConfig) listParamsRequest(w http.ResponseWriter, req *http.Request) (uuid.UUID, string, string, bool) {
+	type parameters struct {
+		Name       string `json:"name"`
+		Visibility string `json:"visibility"`
+	}
+
+	userID, err := cfg.authenticate(req)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized request", err)
+		return uuid.Nil, "", "", false
+	}
+
+	params := parameters{}
+	if err := json.NewDecoder(req.Body).Decode(&params); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Couldn't decode parameters", err)
+		return uuid.Nil, "", "", false
+	}
+
+	name := strings.TrimSpace(params.Name)
+	if name == "" || len([]rune(name)) > maxListNameLength {
+		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("List names must be 1-%d characters", maxListNameLength), nil)
+		return uuid.Nil, "", "", false
+	}
+	switch params.Visibility {
+	case "", listPublic, listPrivate:
+	default:
+		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("visibility must be %s or %s", listPublic, listPrivate), nil)
+		return uuid.Nil, "", "", false
+	}
+	return userID, name, params.Visibility, true
+}
+
+func listVisibleTo(list database.List, viewerID uuid.UUID) bool {
+	return list.Visibility == listPublic || list.OwnerID == viewerID
+}
+
+func listResponse(list database.List) List {
+	return List{
+		ID:              list.ID,
+		CreatedAt:       list.CreatedAt,
+		UpdatedAt:       list.UpdatedAt,
+		OwnerID:         list.OwnerID,
+		Name:            list.Name,
+		Visibility:      list.Visibility,
+		MemberCount:     list.MemberCount,
+		SubscriberCount: list.SubscriberCount,
+	}
+}
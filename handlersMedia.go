@@ -0,0 +1,229 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/google/uuid"
+	"github.com/mjh1207/chirpy/internal/database"
+	"github.com/mjh1207/chirpy/internal/media"
+)
+
+const (
+	maxAltTextLength = 1000
+	// Media is never modified once uploaded, so it can be cached indefinitely.
+	mediaCacheControl = "public, max-age=31536000, immutable"
+	// privateMediaCacheControl is used for media attached to chirps that
+	// aren't public, so shared caches don't hand it to other viewers.
+	privateMediaCacheControl = "private, max-age=31536000, immutable"
+)
+
+func mediaKey(id uuid.UUID) string {
+	return id.String()
+}
+
+func thumbnailKey(id uuid.UUID) string {
+	return id.String() + "-thumb"
+}
+
+func (cfg *apiConfig) handlerUploadMedia(w http.ResponseWriter, req *http.Request) {
+	userID, err := cfg.authenticate(req)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized request", err)
+		return
+	}
+
+	// Leave room for the multipart headers and alt text around the file
+	req.Body = http.MaxBytesReader(w, req.Body, media.MaxUploadSize+64<<10)
+	if err := req.ParseMultipartForm(media.MaxUploadSize); err != nil {
+		respondWithError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("Uploads are limited to %d bytes", media.MaxUploadSize), err)
+		return
+	}
+
+	file, _, err := req.FormFile("file")
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Missing file", err)
+		return
+	}
+	defer file.Close()
+
+	altText := req.FormValue("alt_text")
+	if len(altText) > maxAltTextLength {
+		respondWithError(w, http.StatusBadRequest, "Alt text is too long", nil)
+		return
+	}
+
+	sensitive := false
+	if value := req.FormValue("sensitive"); value != "" {
+		sensitive, err = strconv.ParseBool(value)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "sensitive must be true or false", err)
+			return
+		}
+	}
+
+	data, err := io.ReadAll(io.LimitReader(file, media.MaxUploadSize+1))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Couldn't read file", err)
+		return
+	}
+	if len(data) > media.MaxUploadSize {
+		respondWithError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("Uploads are limited to %d bytes", media.MaxUploadSize), nil)
+		return
+	}
+
+	img, err := media.Process(data)
+	if errors.Is(err, media.ErrUnsupportedType) {
+		respondWithError(w, http.StatusUnsupportedMediaType, "Only JPEG, PNG and GIF images are supported", err)
+		return
+	}
+	if errors.Is(err, media.ErrTooLarge) {
+		respondWithError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("Images are limited to %d pixels and GIFs to %d frames", media.MaxPixels, media.MaxGIFFrames), err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Couldn't read image", err)
+		return
+	}
+
+	id := uuid.New()
+	if err := cfg.mediaStore.Put(req.Context(), mediaKey(id), bytes.NewReader(img.Data)); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't store media", err)
+		return
+	}
+	if err := cfg.mediaStore.Put(req.Context(), thumbnailKey(id), bytes.NewReader(img.Thumbnail)); err != nil {
+		cfg.deleteMediaBlobs(req.Context(), id)
+		respondWithError(w, http.StatusInternalServerError, "Couldn't store thumbnail", err)
+		return
+	}
+
+	mediaFile, err := cfg.db.CreateMediaFile(req.Context(), database.CreateMediaFileParams{
+		ID:                id,
+		UserID:            userID,
+		MimeType:          img.MIMEType,
+		ThumbnailMimeType: img.ThumbnailMIMEType,
+		SizeBytes:         int32(len(img.Data)),
+		Width:             int32(img.Width),
+		Height:            int32(img.Height),
+		AltText:           altText,
+		Sensitive:         sensitive,
+	})
+	if err != nil {
+		cfg.deleteMediaBlobs(req.Context(), id)
+		respondWithError(w, http.StatusInternalServerError, "Couldn't save media", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, mediaResponse(mediaFile))
+}
+
+func (cfg *apiConfig) handlerGetMedia(w http.ResponseWriter, req *http.Request) {
+	cfg.serveMedia(w, req, false)
+}
+
+func (cfg *apiConfig) handlerGetMediaThumbnail(w http.ResponseWriter, req *http.Request) {
+	cfg.serveMedia(w, req, true)
+}
+
+func (cfg *apiConfig) serveMedia(w http.ResponseWriter, req *http.Request, thumbnail bool) {
+	viewerID, err := cfg.viewerID(req)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized request", err)
+		return
+	}
+
+	id, err := uuid.Parse(req.PathValue("mediaID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Not a valid media id", err)
+		return
+	}
+
+	mediaFile, err := cfg.db.GetMediaFile(req.Context(), id)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Media not found", err)
+		return
+	}
+
+	// Attached media is only served to viewers who can see its chirp
+	cacheControl := mediaCacheControl
+	if mediaFile.ChirpID.Valid {
+		chirp, err := cfg.db.GetChirp(req.Context(), mediaFile.ChirpID.UUID)
+		if err != nil {
+			respondWithError(w, http.StatusNotFound, "Media not found", err)
+			return
+		}
+		visible, err := cfg.chirpVisibleTo(req.Context(), chirp, viewerID)
+		if err != nil || !visible {
+			respondWithError(w, http.StatusNotFound, "Media not found", err)
+			return
+		}
+		if chirp.Visibility != visibilityPublic || chirp.PublishAt.Valid || chirp.DeletedAt.Valid || chirp.HiddenAt.Valid {
+			cacheControl = privateMediaCacheControl
+		}
+		if cacheControl == mediaCacheControl {
+			author, err := cfg.db.GetUser(req.Context(), chirp.UserID)
+			if err != nil {
+				respondWithError(w, http.StatusInternalServerError, "Couldn't get media", err)
+				return
+			}
+			if author.Protected {
+				cacheControl = privateMediaCacheControl
+			}
+		}
+	}
+
+	key, mimeType := mediaKey(id), mediaFile.MimeType
+	if thumbnail {
+		key, mimeType = thumbnailKey(id), mediaFile.ThumbnailMimeType
+	}
+
+	etag := fmt.Sprintf("%q", key)
+	w.Header().Set("Cache-Control", cacheControl)
+	w.Header().Set("ETag", etag)
+	if req.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	blob, err := cfg.mediaStore.Get(req.Context(), key)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Media not found", err)
+		return
+	}
+	defer blob.Close()
+
+	w.Header().Set("Content-Type", mimeType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(http.StatusOK)
+	io.Copy(w, blob)
+}
+
+// deleteMediaBlobs removes the stored image and thumbnail for a media file.
+// Failures are only logged: the database row is already gone or never
+// existed, so a leftover blob is unreachable.
+func (cfg *apiConfig) deleteMediaBlobs(ctx context.Context, id uuid.UUID) {
+	for _, key := range []string{mediaKey(id), thumbnailKey(id)} {
+		if err := cfg.mediaStore.Delete(ctx, key); err != nil {
+			log.Printf("Couldn't delete media blob %s: %v", key, err)
+		}
+	}
+}
+
+func mediaResponse(mediaFile database.MediaFile) Media {
+	return Media{
+		ID:           mediaFile.ID,
+		URL:          "/api/media/" + mediaFile.ID.String(),
+		ThumbnailURL: "/api/media/" + mediaFile.ID.String() + "/thumbnail",
+		MimeType:     mediaFile.MimeType,
+		Width:        mediaFile.Width,
+		Height:       mediaFile.Height,
+		AltText:      mediaFile.AltText,
+		Sensitive:    mediaFile.Sensitive,
+	}
+}
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"net/http"
+)
+
+func (cfg *apiConfig) handlerGetMentions(w http.ResponseWriter, req *http.Request) {
+	userID, err := cfg.authenticate(req)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized request", err)
+		return
+	}
+
+	chirps, err := cfg.db.GetChirpsMentioningUser(req.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get mentions", err)
+		return
+	}
+
+	response, err := cfg.chirpsResponse(req.Context(), userID, chirps)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't build chirp response", err)
+		return
+	}
+	cfg.recordImpressions(userID, chirps)
+	respondWithJSON(w, http.StatusOK, response)
+}
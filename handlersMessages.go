@@ -0,0 +1,585 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/mjh1207/chirpy/internal/database"
+	"github.com/mjh1207/chirpy/internal/pagination"
+)
+
+// Who can start a conversation with a user.
+const (
+	dmPolicyEveryone  = "everyone"
+	dmPolicyFollowers = "followers"
+	dmPolicyNobody    = "nobody"
+)
+
+const (
+	// maxConversationParticipants is the most users a conversation can
+	// have, counting its creator.
+	maxConversationParticipants = 10
+	// maxMessageLength is the longest message body in characters.
+	maxMessageLength = 1000
+)
+
+// handlerCreateConversation starts a conversation between the caller and
+// the users in participant_ids, each of whom must accept messages from the
+// caller. There is only one conversation between any two users, so asking
+// for a one-to-one conversation that already exists returns it.
+func (cfg *apiConfig) handlerCreateConversation(w http.ResponseWriter, req *http.Request) {
+	type parameters struct {
+		ParticipantIDs []uuid.UUID `json:"participant_ids"`
+	}
+
+	userID, err := cfg.authenticate(req)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized request", err)
+		return
+	}
+
+	params := parameters{}
+	if err := json.NewDecoder(req.Body).Decode(&params); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Couldn't decode parameters", err)
+		return
+	}
+	others := make([]uuid.UUID, 0, len(params.ParticipantIDs))
+	for _, id := range params.ParticipantIDs {
+		if id != userID && !slices.Contains(others, id) {
+			others = append(others, id)
+		}
+	}
+	if len(others) == 0 {
+		respondWithError(w, http.StatusBadRequest, "participant_ids must name at least one other user", nil)
+		return
+	}
+	if len(others) >= maxConversationParticipants {
+		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Conversations can have at most %d participants", maxConversationParticipants), nil)
+		return
+	}
+
+	tx, err := cfg.conn.BeginTx(req.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't start transaction", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	for _, id := range others {
+		recipient, err := qtx.GetUser(req.Context(), id)
+		if err != nil {
+			respondWithError(w, http.StatusNotFound, "User not found", err)
+			return
+		}
+		allowed, err := canMessage(req.Context(), qtx, userID, recipient)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Couldn't check messaging permissions", err)
+			return
+		}
+		if !allowed {
+			respondWithError(w, http.StatusForbidden, "You can't message this user", nil)
+			return
+		}
+	}
+
+	create := database.CreateConversationParams{
+		CreatorID: uuid.NullUUID{UUID: userID, Valid: true},
+		IsGroup:   len(others) > 1,
+	}
+	if !create.IsGroup {
+		create.DirectKey = sql.NullString{String: directKey(userID, others[0]), Valid: true}
+		existing, err := qtx.GetDirectConversation(req.Context(), create.DirectKey)
+		if err == nil {
+			tx.Rollback()
+			cfg.respondWithConversation(w, req, http.StatusOK, existing.ID, userID)
+			return
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusInternalServerError, "Couldn't get conversation", err)
+			return
+		}
+	}
+
+	conversation, err := qtx.CreateConversation(req.Context(), create)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't create conversation", err)
+		return
+	}
+	err = qtx.AddConversationParticipants(req.Context(), database.AddConversationParticipantsParams{
+		ConversationID: conversation.ID,
+		UserIds:        append(others, userID),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't create conversation", err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't create conversation", err)
+		return
+	}
+	cfg.respondWithConversation(w, req, http.StatusCreated, conversation.ID, userID)
+}
+
+// handlerGetConversations lists the caller's conversations, most recently
+// active first, with how many of them have unread messages.
+func (cfg *apiConfig) handlerGetConversations(w http.ResponseWriter, req *http.Request) {
+	userID, err := cfg.authenticate(req)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized request", err)
+		return
+	}
+
+	cursor, limit, err := parsePage(req)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	params := database.GetUserConversationsParams{
+		UserID:   userID,
+		PageSize: int32(limit + 1),
+	}
+	if cursor != nil {
+		params.BeforeLastMessageAt = sql.NullTime{Time: cursor.Time, Valid: true}
+		params.BeforeConversationID = uuid.NullUUID{UUID: cursor.ID, Valid: true}
+	}
+
+	rows, err := cfg.db.GetUserConversations(req.Context(), params)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get conversations", err)
+		return
+	}
+
+	page := ConversationPage{}
+	if len(rows) > limit {
+		rows = rows[:limit]
+		last := rows[len(rows)-1]
+		page.NextCursor = pagination.Cursor{Time: last.Conversation.LastMessageAt, ID: last.Conversation.ID}.String()
+	}
+	conversations := make([]database.GetUserConversationRow, 0, len(rows))
+	for _, row := range rows {
+		conversations = append(conversations, database.GetUserConversationRow(row))
+	}
+	page.Conversations, err = cfg.conversationsResponse(req.Context(), conversations)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't build conversation response", err)
+		return
+	}
+	page.UnreadConversations, err = cfg.db.CountUnreadConversations(req.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't count unread conversations", err)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, page)
+}
+
+func (cfg *apiConfig) handlerGetConversation(w http.ResponseWriter, req *http.Request) {
+	_, conversation, ok := cfg.conversationRequest(w, req)
+	if !ok {
+		return
+	}
+
+	response, err := cfg.conversationsResponse(req.Context(), []database.GetUserConversationRow{conversation})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't build conversation response", err)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, response[0])
+}
+
+// handlerSendMessage adds a message to a conversation the caller takes part
+// in. The body is encrypted before it is stored. In one-to-one
+// conversations the other user must still accept messages from the caller,
+// and in groups nobody in the group can have blocked the caller or been
+// blocked by them.
+func (cfg *apiConfig) handlerSendMessage(w http.ResponseWriter, req *http.Request) {
+	type parameters struct {
+		Body string `json:"body"`
+	}
+
+	userID, conversation, ok := cfg.conversationRequest(w, req)
+	if !ok {
+		return
+	}
+
+	params := parameters{}
+	if err := json.NewDecoder(req.Body).Decode(&params); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Couldn't decode parameters", err)
+		return
+	}
+	body := strings.TrimSpace(params.Body)
+	if body == "" || len([]rune(body)) > maxMessageLength {
+		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Messages must be 1-%d characters", maxMessageLength), nil)
+		return
+	}
+
+	participants, err := cfg.db.GetConversationParticipants(req.Context(), []uuid.UUID{conversation.Conversation.ID})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get participants", err)
+		return
+	}
+	if conversation.Conversation.IsGroup {
+		others := make([]uuid.UUID, 0, len(participants))
+		for _, participant := range participants {
+			if participant.User.ID != userID {
+				others = append(others, participant.User.ID)
+			}
+		}
+		blocked, err := blockedUsers(req.Context(), cfg.db, userID, others)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Couldn't check messaging permissions", err)
+			return
+		}
+		if len(blocked) > 0 {
+			respondWithError(w, http.StatusForbidden, "You can't message a group with someone you have blocked or who has blocked you", nil)
+			return
+		}
+	} else {
+		for _, participant := range participants {
+			if participant.User.ID == userID {
+				continue
+			}
+			allowed, err := canMessage(req.Context(), cfg.db, userID, participant.User)
+			if err != nil {
+				respondWithError(w, http.StatusInternalServerError, "Couldn't check messaging permissions", err)
+				return
+			}
+			if !allowed {
+				respondWithError(w, http.StatusForbidden, "You can't message this user", nil)
+				return
+			}
+		}
+	}
+
+	messageID := uuid.New()
+	sealed, err := cfg.dmBox.Seal([]byte(body), messageAssociatedData(conversation.Conversation.ID, messageID))
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't encrypt message", err)
+		return
+	}
+
+	tx, err := cfg.conn.BeginTx(req.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't start transaction", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	message, err := qtx.CreateMessage(req.Context(), database.CreateMessageParams{
+		ID:             messageID,
+		ConversationID: conversation.Conversation.ID,
+		SenderID:       userID,
+		Body:           sealed,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't save message", err)
+		return
+	}
+	err = qtx.TouchConversation(req.Context(), database.TouchConversationParams{
+		LastMessageAt: message.CreatedAt,
+		ID:            conversation.Conversation.ID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't save message", err)
+		return
+	}
+	// Senders have read their own messages
+	err = qtx.MarkConversationRead(req.Context(), database.MarkConversationReadParams{
+		ReadAt:         message.CreatedAt,
+		ConversationID: conversation.Conversation.ID,
+		UserID:         userID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't save message", err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't save message", err)
+		return
+	}
+	respondWithJSON(w, http.StatusCreated, Message{
+		ID:             message.ID,
+		ConversationID: message.ConversationID,
+		SenderID:       message.SenderID,
+		CreatedAt:      message.CreatedAt,
+		Body:           body,
+		ReadBy:         []uuid.UUID{},
+	})
+}
+
+// handlerGetMessages lists a conversation's messages, newest first, with
+// the other participants who have read each one.
+func (cfg *apiConfig) handlerGetMessages(w http.ResponseWriter, req *http.Request) {
+	_, conversation, ok := cfg.conversationRequest(w, req)
+	if !ok {
+		return
+	}
+
+	cursor, limit, err := parsePage(req)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	params := database.GetMessagesParams{
+		ConversationID: conversation.Conversation.ID,
+		PageSize:       int32(limit + 1),
+	}
+	if cursor != nil {
+		params.BeforeCreatedAt = sql.NullTime{Time: cursor.Time, Valid: true}
+		params.BeforeMessageID = uuid.NullUUID{UUID: cursor.ID, Valid: true}
+	}
+
+	messages, err := cfg.db.GetMessages(req.Context(), params)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get messages", err)
+		return
+	}
+	participants, err := cfg.db.GetConversationParticipants(req.Context(), []uuid.UUID{conversation.Conversation.ID})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get participants", err)
+		return
+	}
+
+	page := MessagePage{Messages: []Message{}}
+	if len(messages) > limit {
+		messages = messages[:limit]
+		last := messages[len(messages)-1]
+		page.NextCursor = pagination.Cursor{Time: last.CreatedAt, ID: last.ID}.String()
+	}
+	for _, message := range messages {
+		body, err := cfg.dmBox.Open(message.Body, messageAssociatedData(message.ConversationID, message.ID))
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Couldn't decrypt message", err)
+			return
+		}
+		response := Message{
+			ID:             message.ID,
+			ConversationID: message.ConversationID,
+			SenderID:       message.SenderID,
+			CreatedAt:      message.CreatedAt,
+			Body:           string(body),
+			ReadBy:         []uuid.UUID{},
+		}
+		for _, participant := range participants {
+			if participant.User.ID != message.SenderID && participant.LastReadAt.Valid && !participant.LastReadAt.Time.Before(message.CreatedAt) {
+				response.ReadBy = append(response.ReadBy, participant.User.ID)
+			}
+		}
+		page.Messages = append(page.Messages, response)
+	}
+	respondWithJSON(w, http.StatusOK, page)
+}
+
+// handlerDeleteMessage deletes one of the caller's messages for everyone in
+// the conversation.
+func (cfg *apiConfig) handlerDeleteMessage(w http.ResponseWriter, req *http.Request) {
+	userID, conversation, ok := cfg.conversationRequest(w, req)
+	if !ok {
+		return
+	}
+
+	messageID, err := uuid.Parse(req.PathValue("messageID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Not a valid message id", err)
+		return
+	}
+
+	deleted, err := cfg.db.DeleteMessage(req.Context(), database.DeleteMessageParams{
+		ID:             messageID,
+		ConversationID: conversation.Conversation.ID,
+		SenderID:       userID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't delete message", err)
+		return
+	}
+	if deleted == 0 {
+		respondWithError(w, http.StatusNotFound, "Message not found", nil)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handlerMarkConversationRead marks every message in a conversation as read
+// by the caller.
+func (cfg *apiConfig) handlerMarkConversationRead(w http.ResponseWriter, req *http.Request) {
+	userID, conversation, ok := cfg.conversationRequest(w, req)
+	if !ok {
+		return
+	}
+
+	err := cfg.db.MarkConversationRead(req.Context(), database.MarkConversationReadParams{
+		ReadAt:         conversation.Conversation.LastMessageAt,
+		ConversationID: conversation.Conversation.ID,
+		UserID:         userID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't mark conversation read", err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handlerMuteConversation(w http.ResponseWriter, req *http.Request) {
+	cfg.setConversationMuted(w, req, true)
+}
+
+func (cfg *apiConfig) handlerUnmuteConversation(w http.ResponseWriter, req *http.Request) {
+	cfg.setConversationMuted(w, req, false)
+}
+
+// setConversationMuted mutes or unmutes a conversation for the caller.
+// Muted conversations don't count towards the caller's unread
+// conversations.
+func (cfg *apiConfig) setConversationMuted(w http.ResponseWriter, req *http.Request, muted bool) {
+	userID, conversation, ok := cfg.conversationRequest(w, req)
+	if !ok {
+		return
+	}
+
+	err := cfg.db.SetConversationMuted(req.Context(), database.SetConversationMutedParams{
+		Muted:          muted,
+		ConversationID: conversation.Conversation.ID,
+		UserID:         userID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't update conversation", err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// conversationRequest authenticates a request for the conversation in the
+// path, which the caller must take part in. Conversations the caller isn't
+// in are reported as not found. It writes the error response itself and
+// returns false if anything is wrong.
+func (cfg *apiConfig) conversationRequest(w http.ResponseWriter, req *http.Request) (uuid.UUID, database.GetUserConversationRow, bool) {
+	userID, err := cfg.authenticate(req)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized request", err)
+		return uuid.Nil, database.GetUserConversationRow{}, false
+	}
+
+	conversationID, err := uuid.Parse(req.PathValue("conversationID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Not a valid conversation id", err)
+		return uuid.Nil, database.GetUserConversationRow{}, false
+	}
+
+	conversation, err := cfg.db.GetUserConversation(req.Context(), database.GetUserConversationParams{
+		ID:     conversationID,
+		UserID: userID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "Conversation not found", err)
+		return uuid.Nil, database.GetUserConversationRow{}, false
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get conversation", err)
+		return uuid.Nil, database.GetUserConversationRow{}, false
+	}
+	return userID, conversation, true
+}
+
+// respondWithConversation writes conversationID as seen by userID.
+func (cfg *apiConfig) respondWithConversation(w http.ResponseWriter, req *http.Request, code int, conversationID, userID uuid.UUID) {
+	conversation, err := cfg.db.GetUserConversation(req.Context(), database.GetUserConversationParams{
+		ID:     conversationID,
+		UserID: userID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get conversation", err)
+		return
+	}
+	response, err := cfg.conversationsResponse(req.Context(), []database.GetUserConversationRow{conversation})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't build conversation response", err)
+		return
+	}
+	respondWithJSON(w, code, response[0])
+}
+
+// conversationsResponse builds the responses for conversations as seen by
+// one user, looking up every conversation's participants in one query.
+func (cfg *apiConfig) conversationsResponse(ctx context.Context, conversations []database.GetUserConversationRow) ([]Conversation, error) {
+	ids := make([]uuid.UUID, 0, len(conversations))
+	for _, conversation := range conversations {
+		ids = append(ids, conversation.Conversation.ID)
+	}
+	participants, err := cfg.db.GetConversationParticipants(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	byConversation := make(map[uuid.UUID][]ConversationParticipant, len(conversations))
+	for _, participant := range participants {
+		response := ConversationParticipant{User: publicUserResponse(participant.User)}
+		if participant.LastReadAt.Valid {
+			response.LastReadAt = &participant.LastReadAt.Time
+		}
+		byConversation[participant.ConversationID] = append(byConversation[participant.ConversationID], response)
+	}
+
+	response := make([]Conversation, 0, len(conversations))
+	for _, conversation := range conversations {
+		response = append(response, Conversation{
+			ID:            conversation.Conversation.ID,
+			CreatedAt:     conversation.Conversation.CreatedAt,
+			IsGroup:       conversation.Conversation.IsGroup,
+			Participants:  byConversation[conversation.Conversation.ID],
+			LastMessageAt: conversation.Conversation.LastMessageAt,
+			UnreadCount:   conversation.UnreadCount,
+			Muted:         conversation.Muted,
+		})
+	}
+	return response, nil
+}
+
+// canMessage reports whether senderID may message recipient, which depends
+// on the recipient's dm_policy and on neither having blocked the other.
+func canMessage(ctx context.Context, q *database.Queries, senderID uuid.UUID, recipient database.User) (bool, error) {
+	blocked, err := q.BlockExists(ctx, database.BlockExistsParams{
+		UserID:  senderID,
+		OtherID: recipient.ID,
+	})
+	if err != nil || blocked {
+		return false, err
+	}
+	switch recipient.DmPolicy {
+	case dmPolicyEveryone:
+		return true, nil
+	case dmPolicyFollowers:
+		return q.IsFollowing(ctx, database.IsFollowingParams{
+			FollowerID: senderID,
+			FolloweeID: recipient.ID,
+		})
+	default:
+		return false, nil
+	}
+}
+
+// directKey names the one-to-one conversation between two users, whichever
+// of them starts it.
+func directKey(a, b uuid.UUID) string {
+	if a.String() > b.String() {
+		a, b = b, a
+	}
+	return a.String() + ":" + b.String()
+}
+
+// messageAssociatedData binds an encrypted message body to its row, so that
+// it can't be decrypted after being moved to another message.
+func messageAssociatedData(conversationID, messageID uuid.UUID) []byte {
+	return append(conversationID[:], messageID[:]...)
+}
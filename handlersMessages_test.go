@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/mjh1207/chirpy/internal/database"
+)
+
+func TestSendMessageToGroupWithBlock(t *testing.T) {
+	cfg := newTestConfig(t)
+	sender, senderToken := createTestUser(t, cfg, "sender")
+	blocker, _ := createTestUser(t, cfg, "blocker")
+	bystander, bystanderToken := createTestUser(t, cfg, "bystander")
+
+	var conversation Conversation
+	rec := doTestRequest(t, "POST /api/conversations", cfg.handlerCreateConversation, http.MethodPost, "/api/conversations", senderToken,
+		map[string][]uuid.UUID{"participant_ids": {blocker.ID, bystander.ID}}, &conversation)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("Creating conversation: %d %s", rec.Code, rec.Body)
+	}
+
+	_, err := cfg.db.BlockUser(context.Background(), database.BlockUserParams{
+		BlockerID: blocker.ID,
+		BlockedID: sender.ID,
+	})
+	if err != nil {
+		t.Fatalf("Couldn't block: %v", err)
+	}
+
+	const pattern = "POST /api/conversations/{conversationID}/messages"
+	target := "/api/conversations/" + conversation.ID.String() + "/messages"
+	body := map[string]string{"body": "Hello everyone"}
+	rec = doTestRequest(t, pattern, cfg.handlerSendMessage, http.MethodPost, target, senderToken, body, nil)
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("Blocked sender got %d, want %d", rec.Code, http.StatusForbidden)
+	}
+	rec = doTestRequest(t, pattern, cfg.handlerSendMessage, http.MethodPost, target, bystanderToken, body, nil)
+	if rec.Code != http.StatusCreated {
+		t.Errorf("Bystander got %d %s, want %d", rec.Code, rec.Body, http.StatusCreated)
+	}
+}
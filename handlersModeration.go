@@ -0,0 +1,313 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/mjh1207/chirpy/internal/database"
+	"github.com/mjh1207/chirpy/internal/moderation"
+)
+
+// maxModerationReasonLength bounds the reason shown to authors whose chirps
+// are rejected.
+const maxModerationReasonLength = 200
+
+// requireModerator authenticates the request and checks that the caller is
+// a moderator, responding with an error if not.
+func (cfg *apiConfig) requireModerator(w http.ResponseWriter, req *http.Request) (uuid.UUID, bool) {
+	userID, err := cfg.authenticate(req)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized request", err)
+		return uuid.Nil, false
+	}
+	moderator, err := cfg.isModerator(req.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get user", err)
+		return uuid.Nil, false
+	}
+	if !moderator {
+		respondWithError(w, http.StatusForbidden, "Only moderators can do that", nil)
+		return uuid.Nil, false
+	}
+	return userID, true
+}
+
+func (cfg *apiConfig) handlerGetModerationWords(w http.ResponseWriter, req *http.Request) {
+	if _, ok := cfg.requireModerator(w, req); !ok {
+		return
+	}
+
+	words, err := cfg.db.GetModerationWords(req.Context())
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get moderation words", err)
+		return
+	}
+
+	response := make([]ModerationWord, 0, len(words))
+	for _, word := range words {
+		response = append(response, moderationWordResponse(word))
+	}
+	respondWithJSON(w, http.StatusOK, response)
+}
+
+// handlerCreateModerationWord adds a word to the moderation lists. The new
+// list takes effect for the next chirp posted.
+func (cfg *apiConfig) handlerCreateModerationWord(w http.ResponseWriter, req *http.Request) {
+	type parameters struct {
+		Word   string            `json:"word"`
+		Action moderation.Action `json:"action"`
+		Reason string            `json:"reason"`
+	}
+
+	if _, ok := cfg.requireModerator(w, req); !ok {
+		return
+	}
+
+	params := parameters{}
+	if err := json.NewDecoder(req.Body).Decode(&params); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Couldn't decode parameters", err)
+		return
+	}
+
+	word := strings.ToLower(strings.TrimSpace(params.Word))
+	if tokens := moderation.Tokenize(word); len(tokens) != 1 || tokens[0].Text != word {
+		respondWithError(w, http.StatusBadRequest, "word must be a single word", nil)
+		return
+	}
+	if !params.Action.Valid() {
+		respondWithError(w, http.StatusBadRequest, "action must be one of mask, reject or flag", nil)
+		return
+	}
+	if len(params.Reason) > maxModerationReasonLength {
+		respondWithError(w, http.StatusBadRequest, "reason is too long", nil)
+		return
+	}
+
+	created, err := cfg.db.CreateModerationWord(req.Context(), database.CreateModerationWordParams{
+		Word:   word,
+		Action: string(params.Action),
+		Reason: strings.TrimSpace(params.Reason),
+	})
+	if isUniqueViolation(err) {
+		respondWithError(w, http.StatusConflict, "Word is already listed", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't add moderation word", err)
+		return
+	}
+
+	if err := cfg.reloadModeration(req.Context()); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't reload moderation word lists", err)
+		return
+	}
+	respondWithJSON(w, http.StatusCreated, moderationWordResponse(created))
+}
+
+func (cfg *apiConfig) handlerDeleteModerationWord(w http.ResponseWriter, req *http.Request) {
+	if _, ok := cfg.requireModerator(w, req); !ok {
+		return
+	}
+
+	wordID, err := uuid.Parse(req.PathValue("wordID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Not a valid word id", err)
+		return
+	}
+
+	deleted, err := cfg.db.DeleteModerationWord(req.Context(), wordID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't delete moderation word", err)
+		return
+	}
+	if deleted == 0 {
+		respondWithError(w, http.StatusNotFound, "Word not found", nil)
+		return
+	}
+
+	if err := cfg.reloadModeration(req.Context()); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't reload moderation word lists", err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handlerGetChirpFlags lists chirps flagged by the moderation pipeline,
+// newest first.
+func (cfg *apiConfig) handlerGetChirpFlags(w http.ResponseWriter, req *http.Request) {
+	if _, ok := cfg.requireModerator(w, req); !ok {
+		return
+	}
+
+	flags, err := cfg.db.GetChirpFlags(req.Context())
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get flagged chirps", err)
+		return
+	}
+
+	response := make([]ChirpFlag, 0, len(flags))
+	for _, flag := range flags {
+		response = append(response, ChirpFlag{
+			ID:        flag.ID,
+			ChirpID:   flag.ChirpID,
+			CreatedAt: flag.CreatedAt,
+			Filter:    flag.Filter,
+			Reason:    flag.Reason,
+		})
+	}
+	respondWithJSON(w, http.StatusOK, response)
+}
+
+// handlerUnhideChirp makes a hidden chirp visible again, such as one held
+// back by the spam filter that turned out to be fine.
+func (cfg *apiConfig) handlerUnhideChirp(w http.ResponseWriter, req *http.Request) {
+	moderatorID, ok := cfg.requireModerator(w, req)
+	if !ok {
+		return
+	}
+
+	chirpID, err := uuid.Parse(req.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Not a valid chirpID", err)
+		return
+	}
+
+	chirp, err := cfg.db.UnhideChirp(req.Context(), chirpID)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "Hidden chirp not found", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't unhide chirp", err)
+		return
+	}
+
+	response, err := cfg.chirpResponse(req.Context(), moderatorID, chirp)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't build chirp response", err)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, response)
+}
+
+// handlerForceContentWarning puts a content warning on someone else's chirp,
+// optionally marking its media sensitive too. The author can't change or
+// remove a forced warning.
+func (cfg *apiConfig) handlerForceContentWarning(w http.ResponseWriter, req *http.Request) {
+	type parameters struct {
+		ContentWarning string `json:"content_warning"`
+		SensitiveMedia bool   `json:"sensitive_media"`
+	}
+
+	moderatorID, ok := cfg.requireModerator(w, req)
+	if !ok {
+		return
+	}
+
+	chirpID, err := uuid.Parse(req.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Not a valid chirpID", err)
+		return
+	}
+
+	params := parameters{}
+	if err := json.NewDecoder(req.Body).Decode(&params); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Couldn't decode parameters", err)
+		return
+	}
+	contentWarning := strings.TrimSpace(params.ContentWarning)
+	if contentWarning == "" || len([]rune(contentWarning)) > maxContentWarningLength {
+		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("content_warning must be 1-%d characters", maxContentWarningLength), nil)
+		return
+	}
+
+	tx, err := cfg.conn.BeginTx(req.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't start transaction", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	chirp, err := qtx.SetContentWarning(req.Context(), database.SetContentWarningParams{
+		ContentWarning:       contentWarning,
+		ContentWarningForced: true,
+		ID:                   chirpID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "Chirp not found", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't set content warning", err)
+		return
+	}
+	if params.SensitiveMedia {
+		err := qtx.MarkChirpMediaSensitive(req.Context(), uuid.NullUUID{UUID: chirpID, Valid: true})
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Couldn't mark media sensitive", err)
+			return
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't set content warning", err)
+		return
+	}
+
+	response, err := cfg.chirpResponse(req.Context(), moderatorID, chirp)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't build chirp response", err)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, response)
+}
+
+// handlerLiftContentWarning removes a content warning from a chirp so that
+// its author can set their own again.
+func (cfg *apiConfig) handlerLiftContentWarning(w http.ResponseWriter, req *http.Request) {
+	moderatorID, ok := cfg.requireModerator(w, req)
+	if !ok {
+		return
+	}
+
+	chirpID, err := uuid.Parse(req.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Not a valid chirpID", err)
+		return
+	}
+
+	chirp, err := cfg.db.SetContentWarning(req.Context(), database.SetContentWarningParams{
+		ID: chirpID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "Chirp not found", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't remove content warning", err)
+		return
+	}
+
+	response, err := cfg.chirpResponse(req.Context(), moderatorID, chirp)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't build chirp response", err)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, response)
+}
+
+func moderationWordResponse(word database.ModerationWord) ModerationWord {
+	return ModerationWord{
+		ID:        word.ID,
+		CreatedAt: word.CreatedAt,
+		Word:      word.Word,
+		Action:    word.Action,
+		Reason:    word.Reason,
+	}
+}
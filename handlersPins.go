@@ -0,0 +1,90 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/mjh1207/chirpy/internal/database"
+)
+
+// maxPinnedChirps is how many chirps a user can have pinned to their
+// profile at once.
+const maxPinnedChirps = 3
+
+func (cfg *apiConfig) handlerPinChirp(w http.ResponseWriter, req *http.Request) {
+	cfg.setPin(w, req, true)
+}
+
+func (cfg *apiConfig) handlerUnpinChirp(w http.ResponseWriter, req *http.Request) {
+	cfg.setPin(w, req, false)
+}
+
+// setPin pins or unpins one of the caller's own chirps. Pinning a chirp
+// that is already pinned, or unpinning one that isn't, leaves it as it is.
+func (cfg *apiConfig) setPin(w http.ResponseWriter, req *http.Request, pin bool) {
+	userID, err := cfg.authenticate(req)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized request", err)
+		return
+	}
+
+	chirpID, err := uuid.Parse(req.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Not a valid chirpID", err)
+		return
+	}
+
+	chirp, err := cfg.db.GetChirp(req.Context(), chirpID)
+	if err != nil || chirp.DeletedAt.Valid {
+		respondWithError(w, http.StatusNotFound, "Chirp not found", err)
+		return
+	}
+
+	if userID != chirp.UserID {
+		respondWithError(w, http.StatusForbidden, "You do not have permission to pin this Chirp", nil)
+		return
+	}
+
+	switch {
+	case pin == chirp.PinnedAt.Valid:
+		// Already pinned or unpinned as asked
+	case pin:
+		if chirp.PublishAt.Valid {
+			respondWithError(w, http.StatusBadRequest, "Scheduled chirps can't be pinned", nil)
+			return
+		}
+		if chirp.HiddenAt.Valid {
+			respondWithError(w, http.StatusForbidden, "Hidden chirps can't be pinned", nil)
+			return
+		}
+		chirp, err = cfg.db.PinChirp(req.Context(), database.PinChirpParams{
+			ID:        chirpID,
+			UserID:    userID,
+			MaxPinned: maxPinnedChirps,
+		})
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusConflict, fmt.Sprintf("You can pin at most %d chirps", maxPinnedChirps), err)
+			return
+		}
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Couldn't pin chirp", err)
+			return
+		}
+	default:
+		chirp, err = cfg.db.UnpinChirp(req.Context(), chirpID)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Couldn't unpin chirp", err)
+			return
+		}
+	}
+
+	response, err := cfg.chirpResponse(req.Context(), userID, chirp)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't build chirp response", err)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, response)
+}
@@ -0,0 +1,219 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mjh1207/chirpy/internal/database"
+)
+
+const (
+	minPollOptions      = 2
+	maxPollOptions      = 4
+	maxPollOptionLength = 50
+	maxPollDuration     = 7 * 24 * time.Hour
+)
+
+// pollInput is a poll submitted alongside a new chirp.
+type pollInput struct {
+	Options  []string  `json:"options"`
+	ClosesAt time.Time `json:"closes_at"`
+}
+
+// validatePoll checks a submitted poll. opensAt is when the chirp carrying
+// it becomes visible.
+func validatePoll(poll pollInput, opensAt time.Time) error {
+	if len(poll.Options) < minPollOptions || len(poll.Options) > maxPollOptions {
+		return &chirpError{http.StatusBadRequest, fmt.Sprintf("A poll must have %d to %d options", minPollOptions, maxPollOptions)}
+	}
+	for _, option := range poll.Options {
+		if option == "" || len(option) > maxPollOptionLength {
+			return &chirpError{http.StatusBadRequest, fmt.Sprintf("Poll options must be 1-%d characters", maxPollOptionLength)}
+		}
+	}
+	if !poll.ClosesAt.After(opensAt) || poll.ClosesAt.Sub(opensAt) > maxPollDuration {
+		return &chirpError{http.StatusBadRequest, "A poll must close after it opens and within 7 days"}
+	}
+	return nil
+}
+
+func savePoll(ctx context.Context, qtx *database.Queries, chirpID uuid.UUID, poll pollInput) error {
+	err := qtx.CreatePoll(ctx, database.CreatePollParams{
+		ChirpID:  chirpID,
+		ClosesAt: poll.ClosesAt.UTC(),
+	})
+	if err != nil {
+		return err
+	}
+	for i, label := range poll.Options {
+		err := qtx.CreatePollOption(ctx, database.CreatePollOptionParams{
+			ChirpID:  chirpID,
+			Position: int32(i),
+			Label:    label,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// pollsResponse loads the polls attached to chirpIDs. Vote counts are only
+// filled in once the viewer has voted or the poll has closed, so that early
+// results can't sway anyone's vote.
+func (cfg *apiConfig) pollsResponse(ctx context.Context, viewerID uuid.UUID, chirpIDs []uuid.UUID) (map[uuid.UUID]*Poll, error) {
+	polls := map[uuid.UUID]*Poll{}
+	if len(chirpIDs) == 0 {
+		return polls, nil
+	}
+
+	rows, err := cfg.db.GetPollsForChirps(ctx, chirpIDs)
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return polls, nil
+	}
+
+	pollIDs := make([]uuid.UUID, 0, len(rows))
+	for _, row := range rows {
+		pollIDs = append(pollIDs, row.ChirpID)
+		polls[row.ChirpID] = &Poll{
+			ClosesAt: row.ClosesAt,
+			Closed:   !row.ClosesAt.After(time.Now().UTC()),
+			Options:  []PollOption{},
+		}
+	}
+
+	if viewerID != uuid.Nil {
+		votes, err := cfg.db.GetPollVotesForUser(ctx, database.GetPollVotesForUserParams{
+			UserID:   viewerID,
+			ChirpIds: pollIDs,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, vote := range votes {
+			position := vote.Position
+			polls[vote.ChirpID].VotedOption = &position
+		}
+	}
+
+	options, err := cfg.db.GetPollOptionsForChirps(ctx, pollIDs)
+	if err != nil {
+		return nil, err
+	}
+	for _, option := range options {
+		poll := polls[option.ChirpID]
+		response := PollOption{Label: option.Label}
+		if poll.Closed || poll.VotedOption != nil {
+			votes := option.VoteCount
+			response.Votes = &votes
+			if poll.TotalVotes == nil {
+				poll.TotalVotes = new(int32)
+			}
+			*poll.TotalVotes += votes
+		}
+		poll.Options = append(poll.Options, response)
+	}
+
+	return polls, nil
+}
+
+func (cfg *apiConfig) handlerVotePoll(w http.ResponseWriter, req *http.Request) {
+	type parameters struct {
+		Option int32 `json:"option"`
+	}
+
+	userID, err := cfg.authenticate(req)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized request", err)
+		return
+	}
+
+	chirpID, err := uuid.Parse(req.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Not a valid chirpID", err)
+		return
+	}
+
+	decoder := json.NewDecoder(req.Body)
+	params := parameters{}
+	err = decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Couldn't decode parameters", err)
+		return
+	}
+
+	chirp, err := cfg.db.GetChirp(req.Context(), chirpID)
+	if err != nil || chirp.PublishAt.Valid || chirp.DeletedAt.Valid || chirp.HiddenAt.Valid {
+		respondWithError(w, http.StatusNotFound, "Chirp not found", err)
+		return
+	}
+	visible, err := cfg.chirpVisibleTo(req.Context(), chirp, userID)
+	if err != nil || !visible {
+		respondWithError(w, http.StatusNotFound, "Chirp not found", err)
+		return
+	}
+
+	poll, err := cfg.db.GetPoll(req.Context(), chirpID)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Chirp has no poll", err)
+		return
+	}
+	if !poll.ClosesAt.After(time.Now().UTC()) {
+		respondWithError(w, http.StatusConflict, "Poll is closed", nil)
+		return
+	}
+
+	tx, err := cfg.conn.BeginTx(req.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't start transaction", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	counted, err := qtx.IncrementPollOptionVotes(req.Context(), database.IncrementPollOptionVotesParams{
+		ChirpID:  chirpID,
+		Position: params.Option,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't record vote", err)
+		return
+	}
+	if counted == 0 {
+		respondWithError(w, http.StatusBadRequest, "Not a valid poll option", nil)
+		return
+	}
+
+	voted, err := qtx.CreatePollVote(req.Context(), database.CreatePollVoteParams{
+		ChirpID:  chirpID,
+		UserID:   userID,
+		Position: params.Option,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't record vote", err)
+		return
+	}
+	if voted == 0 {
+		respondWithError(w, http.StatusConflict, "You have already voted in this poll", nil)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't record vote", err)
+		return
+	}
+
+	response, err := cfg.chirpResponse(req.Context(), userID, chirp)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't build chirp response", err)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, response)
+}
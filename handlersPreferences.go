@@ -0,0 +1,76 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/mjh1207/chirpy/internal/database"
+)
+
+func (cfg *apiConfig) handlerGetPreferences(w http.ResponseWriter, req *http.Request) {
+	userID, err := cfg.authenticate(req)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized request", err)
+		return
+	}
+
+	user, err := cfg.db.GetUser(req.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get preferences", err)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, preferencesResponse(user))
+}
+
+// handlerUpdatePreferences changes the caller's reading preferences. Fields
+// left out of the request keep their current values.
+func (cfg *apiConfig) handlerUpdatePreferences(w http.ResponseWriter, req *http.Request) {
+	type parameters struct {
+		ExpandContentWarnings *bool `json:"expand_content_warnings"`
+		ShowSensitiveMedia    *bool `json:"show_sensitive_media"`
+	}
+
+	userID, err := cfg.authenticate(req)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized request", err)
+		return
+	}
+
+	params := parameters{}
+	if err := json.NewDecoder(req.Body).Decode(&params); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Couldn't decode parameters", err)
+		return
+	}
+
+	user, err := cfg.db.GetUser(req.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get preferences", err)
+		return
+	}
+
+	update := database.UpdateUserPreferencesParams{
+		ExpandContentWarnings: user.ExpandContentWarnings,
+		ShowSensitiveMedia:    user.ShowSensitiveMedia,
+		ID:                    userID,
+	}
+	if params.ExpandContentWarnings != nil {
+		update.ExpandContentWarnings = *params.ExpandContentWarnings
+	}
+	if params.ShowSensitiveMedia != nil {
+		update.ShowSensitiveMedia = *params.ShowSensitiveMedia
+	}
+
+	user, err = cfg.db.UpdateUserPreferences(req.Context(), update)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't update preferences", err)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, preferencesResponse(user))
+}
+
+func preferencesResponse(user database.User) Preferences {
+	return Preferences{
+		ExpandContentWarnings: user.ExpandContentWarnings,
+		ShowSensitiveMedia:    user.ShowSensitiveMedia,
+	}
+}
@@ -0,0 +1,456 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/mjh1207/chirpy/internal/database"
+)
+
+// maxReportDetailsLength bounds the free text a reporter can add.
+const maxReportDetailsLength = 1000
+
+// reportReasons are the categories a report can be filed under.
+var reportReasons = map[string]bool{
+	"spam":          true,
+	"harassment":    true,
+	"hate":          true,
+	"violence":      true,
+	"sexual":        true,
+	"self_harm":     true,
+	"impersonation": true,
+	"other":         true,
+}
+
+// Resolutions a moderator can choose when closing a report.
+const (
+	resolutionDismiss     = "dismiss"
+	resolutionHideChirp   = "hide_chirp"
+	resolutionSuspendUser = "suspend_user"
+)
+
+// handlerCreateReport files a report about a chirp or, when no chirp is
+// given, about an account.
+func (cfg *apiConfig) handlerCreateReport(w http.ResponseWriter, req *http.Request) {
+	type parameters struct {
+		ChirpID *uuid.UUID `json:"chirp_id"`
+		UserID  *uuid.UUID `json:"user_id"`
+		Reason  string     `json:"reason"`
+		Details string     `json:"details"`
+	}
+
+	userID, err := cfg.authenticate(req)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized request", err)
+		return
+	}
+
+	params := parameters{}
+	if err := json.NewDecoder(req.Body).Decode(&params); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Couldn't decode parameters", err)
+		return
+	}
+
+	if (params.ChirpID == nil) == (params.UserID == nil) {
+		respondWithError(w, http.StatusBadRequest, "Report either a chirp_id or a user_id", nil)
+		return
+	}
+	if !reportReasons[params.Reason] {
+		respondWithError(w, http.StatusBadRequest, "Not a valid report reason", nil)
+		return
+	}
+	if len(params.Details) > maxReportDetailsLength {
+		respondWithError(w, http.StatusBadRequest, "details is too long", nil)
+		return
+	}
+
+	report := database.CreateReportParams{
+		ReporterID: userID,
+		Reason:     params.Reason,
+		Details:    params.Details,
+	}
+	if params.ChirpID != nil {
+		chirp, err := cfg.db.GetChirp(req.Context(), *params.ChirpID)
+		if err != nil {
+			respondWithError(w, http.StatusNotFound, "Chirp not found", err)
+			return
+		}
+		visible, err := cfg.chirpVisibleTo(req.Context(), chirp, userID)
+		if err != nil || !visible {
+			respondWithError(w, http.StatusNotFound, "Chirp not found", err)
+			return
+		}
+		report.ChirpID = uuid.NullUUID{UUID: chirp.ID, Valid: true}
+		report.ReportedUserID = chirp.UserID
+	} else {
+		user, err := cfg.db.GetUser(req.Context(), *params.UserID)
+		if err != nil {
+			respondWithError(w, http.StatusNotFound, "User not found", err)
+			return
+		}
+		report.ReportedUserID = user.ID
+	}
+	if report.ReportedUserID == userID {
+		respondWithError(w, http.StatusBadRequest, "You can't report yourself", nil)
+		return
+	}
+
+	created, err := cfg.db.CreateReport(req.Context(), report)
+	if isUniqueViolation(err) {
+		respondWithError(w, http.StatusConflict, "You have already reported this", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't create report", err)
+		return
+	}
+	respondWithJSON(w, http.StatusCreated, reportResponse(created, nil, false))
+}
+
+// handlerGetMyReports lists the caller's reports along with their outcome.
+func (cfg *apiConfig) handlerGetMyReports(w http.ResponseWriter, req *http.Request) {
+	userID, err := cfg.authenticate(req)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized request", err)
+		return
+	}
+
+	reports, err := cfg.db.GetReportsForReporter(req.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get reports", err)
+		return
+	}
+
+	response := make([]Report, 0, len(reports))
+	for _, report := range reports {
+		response = append(response, reportResponse(report, nil, false))
+	}
+	respondWithJSON(w, http.StatusOK, response)
+}
+
+// handlerGetReportQueue lists reports for moderators, oldest first. Open
+// reports are listed unless ?status= says otherwise, and ?reason= and
+// ?assignee= (a moderator's id, "me" or "unassigned") narrow it further.
+func (cfg *apiConfig) handlerGetReportQueue(w http.ResponseWriter, req *http.Request) {
+	moderatorID, ok := cfg.requireModerator(w, req)
+	if !ok {
+		return
+	}
+
+	query := req.URL.Query()
+	params := database.GetReportQueueParams{}
+	switch status := query.Get("status"); status {
+	case "":
+		params.Status = sql.NullString{String: "open", Valid: true}
+	case "all":
+	case "open", "resolved":
+		params.Status = sql.NullString{String: status, Valid: true}
+	default:
+		respondWithError(w, http.StatusBadRequest, "status must be one of open, resolved or all", nil)
+		return
+	}
+	if reason := query.Get("reason"); reason != "" {
+		if !reportReasons[reason] {
+			respondWithError(w, http.StatusBadRequest, "Not a valid report reason", nil)
+			return
+		}
+		params.Reason = sql.NullString{String: reason, Valid: true}
+	}
+	switch assignee := query.Get("assignee"); assignee {
+	case "":
+	case "unassigned":
+		params.Unassigned = true
+	case "me":
+		params.AssigneeID = uuid.NullUUID{UUID: moderatorID, Valid: true}
+	default:
+		assigneeID, err := uuid.Parse(assignee)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "Not a valid assignee", err)
+			return
+		}
+		params.AssigneeID = uuid.NullUUID{UUID: assigneeID, Valid: true}
+	}
+
+	reports, err := cfg.db.GetReportQueue(req.Context(), params)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get reports", err)
+		return
+	}
+
+	response := make([]Report, 0, len(reports))
+	for _, report := range reports {
+		response = append(response, reportResponse(report, nil, true))
+	}
+	respondWithJSON(w, http.StatusOK, response)
+}
+
+// handlerGetReport shows a single report to a moderator, with every decision
+// taken on it.
+func (cfg *apiConfig) handlerGetReport(w http.ResponseWriter, req *http.Request) {
+	if _, ok := cfg.requireModerator(w, req); !ok {
+		return
+	}
+
+	reportID, err := uuid.Parse(req.PathValue("reportID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Not a valid report id", err)
+		return
+	}
+
+	report, err := cfg.db.GetReport(req.Context(), reportID)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Report not found", err)
+		return
+	}
+	decisions, err := cfg.db.GetReportDecisions(req.Context(), reportID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get report decisions", err)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, reportResponse(report, decisions, true))
+}
+
+// handlerAssignReport assigns an open report to a moderator, the caller
+// unless assignee_id is given.
+func (cfg *apiConfig) handlerAssignReport(w http.ResponseWriter, req *http.Request) {
+	type parameters struct {
+		AssigneeID *uuid.UUID `json:"assignee_id"`
+		Note       string     `json:"note"`
+	}
+
+	moderatorID, ok := cfg.requireModerator(w, req)
+	if !ok {
+		return
+	}
+
+	reportID, err := uuid.Parse(req.PathValue("reportID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Not a valid report id", err)
+		return
+	}
+
+	// The body is optional; an empty one assigns the report to the caller
+	params := parameters{}
+	err = json.NewDecoder(req.Body).Decode(&params)
+	if err != nil && !errors.Is(err, io.EOF) {
+		respondWithError(w, http.StatusBadRequest, "Couldn't decode parameters", err)
+		return
+	}
+
+	assigneeID := moderatorID
+	if params.AssigneeID != nil && *params.AssigneeID != moderatorID {
+		assigneeID = *params.AssigneeID
+		moderator, err := cfg.isModerator(req.Context(), assigneeID)
+		if err != nil || !moderator {
+			respondWithError(w, http.StatusBadRequest, "Reports can only be assigned to moderators", err)
+			return
+		}
+	}
+
+	cfg.updateReportAssignee(w, req, reportID, moderatorID, uuid.NullUUID{UUID: assigneeID, Valid: true}, params.Note)
+}
+
+func (cfg *apiConfig) handlerUnassignReport(w http.ResponseWriter, req *http.Request) {
+	moderatorID, ok := cfg.requireModerator(w, req)
+	if !ok {
+		return
+	}
+
+	reportID, err := uuid.Parse(req.PathValue("reportID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Not a valid report id", err)
+		return
+	}
+
+	cfg.updateReportAssignee(w, req, reportID, moderatorID, uuid.NullUUID{}, "")
+}
+
+// updateReportAssignee changes who an open report is assigned to and records
+// the decision.
+func (cfg *apiConfig) updateReportAssignee(w http.ResponseWriter, req *http.Request, reportID, moderatorID uuid.UUID, assigneeID uuid.NullUUID, note string) {
+	if len(note) > maxReportDetailsLength {
+		respondWithError(w, http.StatusBadRequest, "note is too long", nil)
+		return
+	}
+
+	tx, err := cfg.conn.BeginTx(req.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't start transaction", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	report, err := qtx.AssignReport(req.Context(), database.AssignReportParams{
+		AssigneeID: assigneeID,
+		ID:         reportID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "Open report not found", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't assign report", err)
+		return
+	}
+
+	action := "assign"
+	if !assigneeID.Valid {
+		action = "unassign"
+	}
+	err = qtx.CreateReportDecision(req.Context(), database.CreateReportDecisionParams{
+		ReportID:    reportID,
+		ModeratorID: uuid.NullUUID{UUID: moderatorID, Valid: true},
+		Action:      action,
+		Note:        note,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't record decision", err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't assign report", err)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, reportResponse(report, nil, true))
+}
+
+// handlerResolveReport closes an open report. Hiding the chirp or
+// suspending the reported user happens in the same transaction, so a report
+// is never marked resolved without its action taking effect.
+func (cfg *apiConfig) handlerResolveReport(w http.ResponseWriter, req *http.Request) {
+	type parameters struct {
+		Action string `json:"action"`
+		Note   string `json:"note"`
+	}
+
+	moderatorID, ok := cfg.requireModerator(w, req)
+	if !ok {
+		return
+	}
+
+	reportID, err := uuid.Parse(req.PathValue("reportID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Not a valid report id", err)
+		return
+	}
+
+	params := parameters{}
+	if err := json.NewDecoder(req.Body).Decode(&params); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Couldn't decode parameters", err)
+		return
+	}
+	switch params.Action {
+	case resolutionDismiss, resolutionHideChirp, resolutionSuspendUser:
+	default:
+		respondWithError(w, http.StatusBadRequest, "action must be one of dismiss, hide_chirp or suspend_user", nil)
+		return
+	}
+	if len(params.Note) > maxReportDetailsLength {
+		respondWithError(w, http.StatusBadRequest, "note is too long", nil)
+		return
+	}
+
+	tx, err := cfg.conn.BeginTx(req.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't start transaction", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	report, err := qtx.ResolveReport(req.Context(), database.ResolveReportParams{
+		Resolution: sql.NullString{String: params.Action, Valid: true},
+		ID:         reportID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "Open report not found", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't resolve report", err)
+		return
+	}
+
+	switch params.Action {
+	case resolutionHideChirp:
+		if !report.ChirpID.Valid {
+			respondWithError(w, http.StatusBadRequest, "Report isn't about a chirp that still exists", nil)
+			return
+		}
+		err = qtx.HideChirp(req.Context(), report.ChirpID.UUID)
+	case resolutionSuspendUser:
+		err = qtx.SuspendUser(req.Context(), report.ReportedUserID)
+		if err == nil {
+			err = qtx.RevokeTokensForUser(req.Context(), report.ReportedUserID)
+		}
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't apply resolution", err)
+		return
+	}
+
+	err = qtx.CreateReportDecision(req.Context(), database.CreateReportDecisionParams{
+		ReportID:    reportID,
+		ModeratorID: uuid.NullUUID{UUID: moderatorID, Valid: true},
+		Action:      params.Action,
+		Note:        params.Note,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't record decision", err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't resolve report", err)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, reportResponse(report, nil, true))
+}
+
+// reportResponse converts a report into its API representation. Reporters
+// see the outcome of their reports; who handled them and the decision log
+// are only shown to moderators.
+func reportResponse(report database.Report, decisions []database.ReportDecision, forModerator bool) Report {
+	r := Report{
+		ID:             report.ID,
+		CreatedAt:      report.CreatedAt,
+		UpdatedAt:      report.UpdatedAt,
+		ReportedUserID: report.ReportedUserID,
+		Reason:         report.Reason,
+		Details:        report.Details,
+		Status:         report.Status,
+		Resolution:     report.Resolution.String,
+	}
+	if report.ChirpID.Valid {
+		r.ChirpID = &report.ChirpID.UUID
+	}
+	if report.ResolvedAt.Valid {
+		r.ResolvedAt = &report.ResolvedAt.Time
+	}
+	if !forModerator {
+		return r
+	}
+
+	r.ReporterID = &report.ReporterID
+	if report.AssigneeID.Valid {
+		r.AssigneeID = &report.AssigneeID.UUID
+	}
+	for _, decision := range decisions {
+		d := ReportDecision{
+			CreatedAt: decision.CreatedAt,
+			Action:    decision.Action,
+			Note:      decision.Note,
+		}
+		if decision.ModeratorID.Valid {
+			d.ModeratorID = &decision.ModeratorID.UUID
+		}
+		r.Decisions = append(r.Decisions, d)
+	}
+	return r
+}
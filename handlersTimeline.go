@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/mjh1207/chirpy/internal/database"
+	"github.com/mjh1207/chirpy/internal/pagination"
+)
+
+const (
+	// fanOutMaxFollowers is the most followers an account can have and
+	// still have its chirps copied into every follower's timeline. Chirps
+	// from bigger accounts are read from chirps when a timeline is fetched.
+	// An account's size is checked when each chirp is posted.
+	fanOutMaxFollowers = 10_000
+	// timelineBackfillSize is how many of an account's recent chirps are
+	// added to a timeline when the account is followed.
+	timelineBackfillSize = 100
+)
+
+// fanOutChirps adds newly published chirps to the timelines of their
+// authors and the authors' followers.
+func fanOutChirps(ctx context.Context, qtx *database.Queries, chirpIDs []uuid.UUID) error {
+	return qtx.FanOutChirps(ctx, database.FanOutChirpsParams{
+		ChirpIds:     chirpIDs,
+		MaxFollowers: fanOutMaxFollowers,
+	})
+}
+
+// handlerGetHomeTimeline lists chirps from the accounts the caller follows,
+// and the caller's own, newest first.
+func (cfg *apiConfig) handlerGetHomeTimeline(w http.ResponseWriter, req *http.Request) {
+	userID, err := cfg.authenticate(req)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized request", err)
+		return
+	}
+
+	cursor, limit, err := parsePage(req)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	params := database.GetHomeTimelineParams{
+		UserID:       userID,
+		PageSize:     int32(limit + 1),
+		MaxFollowers: fanOutMaxFollowers,
+	}
+	if cursor != nil {
+		params.BeforeCreatedAt = sql.NullTime{Time: cursor.Time, Valid: true}
+		params.BeforeChirpID = uuid.NullUUID{UUID: cursor.ID, Valid: true}
+	}
+
+	chirps, err := cfg.db.GetHomeTimeline(req.Context(), params)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get timeline", err)
+		return
+	}
+
+	page := TimelinePage{}
+	if len(chirps) > limit {
+		chirps = chirps[:limit]
+		last := chirps[len(chirps)-1]
+		page.NextCursor = pagination.Cursor{Time: last.CreatedAt, ID: last.ID}.String()
+	}
+
+	page.Chirps, err = cfg.chirpsResponse(req.Context(), userID, chirps)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't build chirp response", err)
+		return
+	}
+	cfg.recordImpressions(userID, chirps)
+	respondWithJSON(w, http.StatusOK, page)
+}
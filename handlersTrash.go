@@ -0,0 +1,84 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mjh1207/chirpy/internal/database"
+)
+
+func (cfg *apiConfig) handlerGetDeletedChirps(w http.ResponseWriter, req *http.Request) {
+	userID, err := cfg.authenticate(req)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized request", err)
+		return
+	}
+
+	// Moderators may look through anyone's trash
+	authorID := userID
+	if authorParam := req.URL.Query().Get("author_id"); authorParam != "" {
+		authorID, err = uuid.Parse(authorParam)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "Not a valid author id", err)
+			return
+		}
+		if authorID != userID {
+			moderator, err := cfg.isModerator(req.Context(), userID)
+			if err != nil || !moderator {
+				respondWithError(w, http.StatusForbidden, "Only moderators can view other users' deleted chirps", err)
+				return
+			}
+		}
+	}
+
+	chirps, err := cfg.db.GetDeletedChirpsForUser(req.Context(), authorID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't get deleted chirps", err)
+		return
+	}
+
+	response, err := cfg.chirpsResponse(req.Context(), userID, chirps)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't build chirp response", err)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, response)
+}
+
+func (cfg *apiConfig) handlerRestoreChirp(w http.ResponseWriter, req *http.Request) {
+	userID, err := cfg.authenticate(req)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unauthorized request", err)
+		return
+	}
+
+	chirpID, err := uuid.Parse(req.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Not a valid chirpID", err)
+		return
+	}
+
+	chirp, err := cfg.db.RestoreChirp(req.Context(), database.RestoreChirpParams{
+		ID:           chirpID,
+		UserID:       userID,
+		DeletedAfter: time.Now().UTC().Add(-chirpTrashWindow),
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "No deleted chirp to restore", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't restore chirp", err)
+		return
+	}
+
+	response, err := cfg.chirpResponse(req.Context(), userID, chirp)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't build chirp response", err)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, response)
+}
@@ -0,0 +1,130 @@
+// Package analytics counts engagement with chirps in memory so that it can
+// be written to the database in batches instead of on every read.
+package analytics
+
+import (
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Event is something that happened to a chirp.
+type Event int
+
+const (
+	// Impression is a chirp being shown in a list.
+	Impression Event = iota
+	// View is a chirp being fetched on its own.
+	View
+	// Like is a chirp being liked.
+	Like
+	// Reply is a reply to a chirp being posted.
+	Reply
+)
+
+// Key identifies the hour of a chirp's life that counts are rolled up into.
+type Key struct {
+	ChirpID uuid.UUID
+	Hour    time.Time
+}
+
+// Counts are the number of each event recorded for a Key.
+type Counts struct {
+	Impressions int64
+	Views       int64
+	Likes       int64
+	Replies     int64
+}
+
+func (c *Counts) add(other Counts) {
+	c.Impressions += other.Impressions
+	c.Views += other.Views
+	c.Likes += other.Likes
+	c.Replies += other.Replies
+}
+
+func eventCounts(event Event) Counts {
+	switch event {
+	case Impression:
+		return Counts{Impressions: 1}
+	case View:
+		return Counts{Views: 1}
+	case Like:
+		return Counts{Likes: 1}
+	case Reply:
+		return Counts{Replies: 1}
+	}
+	return Counts{}
+}
+
+// Buffer accumulates counts per chirp and hour until they are drained. It
+// holds at most maxKeys keys; events for new keys beyond that are dropped
+// so that a stalled flush can't use unbounded memory. It is safe for
+// concurrent use.
+type Buffer struct {
+	maxKeys int
+	now     func() time.Time
+
+	mu      sync.Mutex
+	counts  map[Key]Counts
+	dropped int64
+}
+
+// NewBuffer returns an empty Buffer that holds at most maxKeys keys.
+func NewBuffer(maxKeys int) *Buffer {
+	return &Buffer{
+		maxKeys: maxKeys,
+		now:     time.Now,
+		counts:  map[Key]Counts{},
+	}
+}
+
+// Record counts event against chirpID in the current hour. It reports
+// false if the event was dropped because the buffer is full.
+func (b *Buffer) Record(chirpID uuid.UUID, event Event) bool {
+	key := Key{ChirpID: chirpID, Hour: b.now().UTC().Truncate(time.Hour)}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.add(key, eventCounts(event))
+}
+
+// Drain returns everything recorded since the last Drain and empties the
+// buffer.
+func (b *Buffer) Drain() map[Key]Counts {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	counts := b.counts
+	b.counts = map[Key]Counts{}
+	return counts
+}
+
+// Restore puts counts returned by Drain back into the buffer, for when
+// writing them out failed. They are merged with anything recorded since.
+func (b *Buffer) Restore(counts map[Key]Counts) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for key, c := range counts {
+		b.add(key, c)
+	}
+}
+
+// Dropped returns how many events have been dropped because the buffer was
+// full.
+func (b *Buffer) Dropped() int64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.dropped
+}
+
+func (b *Buffer) add(key Key, c Counts) bool {
+	current, ok := b.counts[key]
+	if !ok && len(b.counts) >= b.maxKeys {
+		b.dropped += c.Impressions + c.Views + c.Likes + c.Replies
+		return false
+	}
+	current.add(c)
+	b.counts[key] = current
+	return true
+}
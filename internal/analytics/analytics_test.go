@@ -0,0 +1,74 @@
+package analytics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestRecordRollsUpByHour(t *testing.T) {
+	now := time.Date(2025, 4, 10, 9, 15, 0, 0, time.UTC)
+	b := NewBuffer(10)
+	b.now = func() time.Time { return now }
+	chirpID := uuid.New()
+
+	b.Record(chirpID, Impression)
+	b.Record(chirpID, Impression)
+	b.Record(chirpID, View)
+	now = now.Add(50 * time.Minute)
+	b.Record(chirpID, Like)
+	b.Record(chirpID, Reply)
+
+	counts := b.Drain()
+	if len(counts) != 2 {
+		t.Fatalf("Drain returned %d keys, want 2", len(counts))
+	}
+	nine := counts[Key{ChirpID: chirpID, Hour: time.Date(2025, 4, 10, 9, 0, 0, 0, time.UTC)}]
+	if want := (Counts{Impressions: 2, Views: 1}); nine != want {
+		t.Errorf("counts for 09:00 = %+v, want %+v", nine, want)
+	}
+	ten := counts[Key{ChirpID: chirpID, Hour: time.Date(2025, 4, 10, 10, 0, 0, 0, time.UTC)}]
+	if want := (Counts{Likes: 1, Replies: 1}); ten != want {
+		t.Errorf("counts for 10:00 = %+v, want %+v", ten, want)
+	}
+
+	if counts := b.Drain(); len(counts) != 0 {
+		t.Errorf("second Drain returned %d keys, want 0", len(counts))
+	}
+}
+
+func TestRecordDropsNewKeysWhenFull(t *testing.T) {
+	b := NewBuffer(1)
+	first, second := uuid.New(), uuid.New()
+
+	if !b.Record(first, View) {
+		t.Fatal("first event was dropped")
+	}
+	if b.Record(second, View) {
+		t.Error("event for a new key was recorded in a full buffer")
+	}
+	if !b.Record(first, View) {
+		t.Error("event for an existing key was dropped")
+	}
+	if got := b.Dropped(); got != 1 {
+		t.Errorf("Dropped() = %d, want 1", got)
+	}
+}
+
+func TestRestoreMergesCounts(t *testing.T) {
+	now := time.Date(2025, 4, 10, 9, 0, 0, 0, time.UTC)
+	b := NewBuffer(10)
+	b.now = func() time.Time { return now }
+	chirpID := uuid.New()
+
+	b.Record(chirpID, Impression)
+	drained := b.Drain()
+	b.Record(chirpID, Impression)
+	b.Restore(drained)
+
+	key := Key{ChirpID: chirpID, Hour: now}
+	if got := b.Drain()[key]; got.Impressions != 2 {
+		t.Errorf("Impressions after Restore = %d, want 2", got.Impressions)
+	}
+}
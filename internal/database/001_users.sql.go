@@ -7,29 +7,32 @@ package database
 
 import (
 	"context"
+	"database/sql"
 
 	"github.com/google/uuid"
 )
 
 const createUser = `-- name: CreateUser :one
-INSERT INTO users (id, created_at, updated_at, email, hashed_password)
+INSERT INTO users (id, created_at, updated_at, email, hashed_password, handle)
 VALUES (
     gen_random_uuid(),
     NOW(),
     NOW(),
     $1,
-    $2
+    $2,
+    $3
 )
-RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, handle, is_moderator, suspended_at, expand_content_warnings, show_sensitive_media, follower_count, following_count, protected, dm_policy
 `
 
 type CreateUserParams struct {
 	Email          string
 	HashedPassword string
+	Handle         sql.NullString
 }
 
 func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, error) {
-	row := q.db.QueryRowContext(ctx, createUser, arg.Email, arg.HashedPassword)
+	row := q.db.QueryRowContext(ctx, createUser, arg.Email, arg.HashedPassword, arg.Handle)
 	var i User
 	err := row.Scan(
 		&i.ID,
@@ -38,6 +41,15 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		&i.Email,
 		&i.HashedPassword,
 		&i.IsChirpyRed,
+		&i.Handle,
+		&i.IsModerator,
+		&i.SuspendedAt,
+		&i.ExpandContentWarnings,
+		&i.ShowSensitiveMedia,
+		&i.FollowerCount,
+		&i.FollowingCount,
+		&i.Protected,
+		&i.DmPolicy,
 	)
 	return i, err
 }
@@ -51,8 +63,36 @@ func (q *Queries) DeleteUsers(ctx context.Context) error {
 	return err
 }
 
+const getUser = `-- name: GetUser :one
+SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red, handle, is_moderator, suspended_at, expand_content_warnings, show_sensitive_media, follower_count, following_count, protected, dm_policy FROM users
+WHERE id = $1
+`
+
+func (q *Queries) GetUser(ctx context.Context, id uuid.UUID) (User, error) {
+	row := q.db.QueryRowContext(ctx, getUser, id)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.Handle,
+		&i.IsModerator,
+		&i.SuspendedAt,
+		&i.ExpandContentWarnings,
+		&i.ShowSensitiveMedia,
+		&i.FollowerCount,
+		&i.FollowingCount,
+		&i.Protected,
+		&i.DmPolicy,
+	)
+	return i, err
+}
+
 const getUserByEmail = `-- name: GetUserByEmail :one
-SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red FROM users
+SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red, handle, is_moderator, suspended_at, expand_content_warnings, show_sensitive_media, follower_count, following_count, protected, dm_policy FROM users
 WHERE email = $1
 `
 
@@ -66,25 +106,119 @@ func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error
 		&i.Email,
 		&i.HashedPassword,
 		&i.IsChirpyRed,
+		&i.Handle,
+		&i.IsModerator,
+		&i.SuspendedAt,
+		&i.ExpandContentWarnings,
+		&i.ShowSensitiveMedia,
+		&i.FollowerCount,
+		&i.FollowingCount,
+		&i.Protected,
+		&i.DmPolicy,
 	)
 	return i, err
 }
 
 const updateUser = `-- name: UpdateUser :one
 UPDATE users
-SET email = $1, hashed_password = $2, updated_at = NOW()
-WHERE id = $3
-RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red
+SET email = $1,
+    hashed_password = $2,
+    handle = COALESCE($3, handle),
+    updated_at = NOW()
+WHERE id = $4
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, handle, is_moderator, suspended_at, expand_content_warnings, show_sensitive_media, follower_count, following_count, protected, dm_policy
 `
 
 type UpdateUserParams struct {
 	Email          string
 	HashedPassword string
+	Handle         sql.NullString
 	ID             uuid.UUID
 }
 
 func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) (User, error) {
-	row := q.db.QueryRowContext(ctx, updateUser, arg.Email, arg.HashedPassword, arg.ID)
+	row := q.db.QueryRowContext(ctx, updateUser,
+		arg.Email,
+		arg.HashedPassword,
+		arg.Handle,
+		arg.ID,
+	)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.Handle,
+		&i.IsModerator,
+		&i.SuspendedAt,
+		&i.ExpandContentWarnings,
+		&i.ShowSensitiveMedia,
+		&i.FollowerCount,
+		&i.FollowingCount,
+		&i.Protected,
+		&i.DmPolicy,
+	)
+	return i, err
+}
+
+const updateUserPreferences = `-- name: UpdateUserPreferences :one
+UPDATE users
+SET expand_content_warnings = $1,
+    show_sensitive_media = $2,
+    updated_at = NOW()
+WHERE id = $3
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, handle, is_moderator, suspended_at, expand_content_warnings, show_sensitive_media, follower_count, following_count, protected, dm_policy
+`
+
+type UpdateUserPreferencesParams struct {
+	ExpandContentWarnings bool
+	ShowSensitiveMedia    bool
+	ID                    uuid.UUID
+}
+
+func (q *Queries) UpdateUserPreferences(ctx context.Context, arg UpdateUserPreferencesParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, updateUserPreferences, arg.ExpandContentWarnings, arg.ShowSensitiveMedia, arg.ID)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.Handle,
+		&i.IsModerator,
+		&i.SuspendedAt,
+		&i.ExpandContentWarnings,
+		&i.ShowSensitiveMedia,
+		&i.FollowerCount,
+		&i.FollowingCount,
+		&i.Protected,
+		&i.DmPolicy,
+	)
+	return i, err
+}
+
+const updateUserPrivacy = `-- name: UpdateUserPrivacy :one
+UPDATE users
+SET protected = $1,
+    dm_policy = $2,
+    updated_at = NOW()
+WHERE id = $3
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, handle, is_moderator, suspended_at, expand_content_warnings, show_sensitive_media, follower_count, following_count, protected, dm_policy
+`
+
+type UpdateUserPrivacyParams struct {
+	Protected bool
+	DmPolicy  string
+	ID        uuid.UUID
+}
+
+func (q *Queries) UpdateUserPrivacy(ctx context.Context, arg UpdateUserPrivacyParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, updateUserPrivacy, arg.Protected, arg.DmPolicy, arg.ID)
 	var i User
 	err := row.Scan(
 		&i.ID,
@@ -93,6 +227,15 @@ func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) (User, e
 		&i.Email,
 		&i.HashedPassword,
 		&i.IsChirpyRed,
+		&i.Handle,
+		&i.IsModerator,
+		&i.SuspendedAt,
+		&i.ExpandContentWarnings,
+		&i.ShowSensitiveMedia,
+		&i.FollowerCount,
+		&i.FollowingCount,
+		&i.Protected,
+		&i.DmPolicy,
 	)
 	return i, err
 }
@@ -100,12 +243,12 @@ func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) (User, e
 const upgradeUser = `-- name: UpgradeUser :one
 UPDATE users
 SET is_chirpy_red = true
+WHERE id = $1
 RETURNING id
 `
 
-func (q *Queries) UpgradeUser(ctx context.Context) (uuid.UUID, error) {
-	row := q.db.QueryRowContext(ctx, upgradeUser)
-	var id uuid.UUID
+func (q *Queries) UpgradeUser(ctx context.Context, id uuid.UUID) (uuid.UUID, error) {
+	row := q.db.QueryRowContext(ctx, upgradeUser, id)
 	err := row.Scan(&id)
 	return id, err
 }
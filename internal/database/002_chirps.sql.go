@@ -7,29 +7,62 @@ package database
 
 import (
 	"context"
+	"database/sql"
 
 	"github.com/google/uuid"
 )
 
+const canViewChirp = `-- name: CanViewChirp :one
+SELECT chirp_visible_to(id, user_id, visibility, $1::uuid) FROM chirps
+WHERE id = $2
+`
+
+type CanViewChirpParams struct {
+	ViewerID uuid.UUID
+	ID       uuid.UUID
+}
+
+func (q *Queries) CanViewChirp(ctx context.Context, arg CanViewChirpParams) (bool, error) {
+	row := q.db.QueryRowContext(ctx, canViewChirp, arg.ViewerID, arg.ID)
+	var chirp_visible_to bool
+	err := row.Scan(&chirp_visible_to)
+	return chirp_visible_to, err
+}
+
 const createChirp = `-- name: CreateChirp :one
-INSERT INTO chirps (id, created_at, updated_at, body, user_id)
+INSERT INTO chirps (id, created_at, updated_at, body, user_id, publish_at, visibility, content_warning, in_reply_to_id)
 VALUES (
     gen_random_uuid(),
     NOW(),
     NOW(),
     $1,
-    $2
+    $2,
+    $3,
+    $4,
+    $5,
+    $6
 )
-RETURNING id, created_at, updated_at, body, user_id
+RETURNING id, created_at, updated_at, body, user_id, like_count, publish_at, deleted_at, visibility, hidden_at, pinned_at, content_warning, content_warning_forced, in_reply_to_id
 `
 
 type CreateChirpParams struct {
-	Body   string
-	UserID uuid.UUID
+	Body           string
+	UserID         uuid.UUID
+	PublishAt      sql.NullTime
+	Visibility     string
+	ContentWarning string
+	InReplyToID    uuid.NullUUID
 }
 
 func (q *Queries) CreateChirp(ctx context.Context, arg CreateChirpParams) (Chirp, error) {
-	row := q.db.QueryRowContext(ctx, createChirp, arg.Body, arg.UserID)
+	row := q.db.QueryRowContext(ctx, createChirp,
+		arg.Body,
+		arg.UserID,
+		arg.PublishAt,
+		arg.Visibility,
+		arg.ContentWarning,
+		arg.InReplyToID,
+	)
 	var i Chirp
 	err := row.Scan(
 		&i.ID,
@@ -37,6 +70,15 @@ func (q *Queries) CreateChirp(ctx context.Context, arg CreateChirpParams) (Chirp
 		&i.UpdatedAt,
 		&i.Body,
 		&i.UserID,
+		&i.LikeCount,
+		&i.PublishAt,
+		&i.DeletedAt,
+		&i.Visibility,
+		&i.HiddenAt,
+		&i.PinnedAt,
+		&i.ContentWarning,
+		&i.ContentWarningForced,
+		&i.InReplyToID,
 	)
 	return i, err
 }
@@ -51,42 +93,8 @@ func (q *Queries) DeleteChirp(ctx context.Context, id uuid.UUID) error {
 	return err
 }
 
-const getAllChirps = `-- name: GetAllChirps :many
-SELECT id, created_at, updated_at, body, user_id FROM chirps
-ORDER BY created_at
-`
-
-func (q *Queries) GetAllChirps(ctx context.Context) ([]Chirp, error) {
-	rows, err := q.db.QueryContext(ctx, getAllChirps)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	var items []Chirp
-	for rows.Next() {
-		var i Chirp
-		if err := rows.Scan(
-			&i.ID,
-			&i.CreatedAt,
-			&i.UpdatedAt,
-			&i.Body,
-			&i.UserID,
-		); err != nil {
-			return nil, err
-		}
-		items = append(items, i)
-	}
-	if err := rows.Close(); err != nil {
-		return nil, err
-	}
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
-	return items, nil
-}
-
 const getChirp = `-- name: GetChirp :one
-SELECT id, created_at, updated_at, body, user_id FROM chirps
+SELECT id, created_at, updated_at, body, user_id, like_count, publish_at, deleted_at, visibility, hidden_at, pinned_at, content_warning, content_warning_forced, in_reply_to_id FROM chirps
 WHERE id = $1
 `
 
@@ -99,41 +107,50 @@ func (q *Queries) GetChirp(ctx context.Context, id uuid.UUID) (Chirp, error) {
 		&i.UpdatedAt,
 		&i.Body,
 		&i.UserID,
+		&i.LikeCount,
+		&i.PublishAt,
+		&i.DeletedAt,
+		&i.Visibility,
+		&i.HiddenAt,
+		&i.PinnedAt,
+		&i.ContentWarning,
+		&i.ContentWarningForced,
+		&i.InReplyToID,
 	)
 	return i, err
 }
 
-const getChirpsForUser = `-- name: GetChirpsForUser :many
-SELECT id, created_at, updated_at, body, user_id FROM chirps
-WHERE user_id = $1
-ORDER BY created_at
+const updateChirpBody = `-- name: UpdateChirpBody :one
+UPDATE chirps
+SET body = $1, content_warning = $2, updated_at = NOW()
+WHERE id = $3
+RETURNING id, created_at, updated_at, body, user_id, like_count, publish_at, deleted_at, visibility, hidden_at, pinned_at, content_warning, content_warning_forced, in_reply_to_id
 `
 
-func (q *Queries) GetChirpsForUser(ctx context.Context, userID uuid.UUID) ([]Chirp, error) {
-	rows, err := q.db.QueryContext(ctx, getChirpsForUser, userID)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	var items []Chirp
-	for rows.Next() {
-		var i Chirp
-		if err := rows.Scan(
-			&i.ID,
-			&i.CreatedAt,
-			&i.UpdatedAt,
-			&i.Body,
-			&i.UserID,
-		); err != nil {
-			return nil, err
-		}
-		items = append(items, i)
-	}
-	if err := rows.Close(); err != nil {
-		return nil, err
-	}
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
-	return items, nil
+type UpdateChirpBodyParams struct {
+	Body           string
+	ContentWarning string
+	ID             uuid.UUID
+}
+
+func (q *Queries) UpdateChirpBody(ctx context.Context, arg UpdateChirpBodyParams) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, updateChirpBody, arg.Body, arg.ContentWarning, arg.ID)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.LikeCount,
+		&i.PublishAt,
+		&i.DeletedAt,
+		&i.Visibility,
+		&i.HiddenAt,
+		&i.PinnedAt,
+		&i.ContentWarning,
+		&i.ContentWarningForced,
+		&i.InReplyToID,
+	)
+	return i, err
 }
@@ -63,7 +63,7 @@ func (q *Queries) GetRefreshToken(ctx context.Context, token string) (RefreshTok
 }
 
 const getUserFromRefreshToken = `-- name: GetUserFromRefreshToken :one
-SELECT users.id, users.created_at, users.updated_at, users.email, users.hashed_password, users.is_chirpy_red FROM users
+SELECT users.id, users.created_at, users.updated_at, users.email, users.hashed_password, users.is_chirpy_red, users.handle, users.is_moderator, users.suspended_at, users.expand_content_warnings, users.show_sensitive_media, users.follower_count, users.following_count, users.protected, users.dm_policy FROM users
 INNER JOIN refresh_tokens ON users.id = refresh_tokens.user_id
 WHERE refresh_tokens.token = $1
 AND refresh_tokens.expires_at > NOW()
@@ -80,6 +80,15 @@ func (q *Queries) GetUserFromRefreshToken(ctx context.Context, token string) (Us
 		&i.Email,
 		&i.HashedPassword,
 		&i.IsChirpyRed,
+		&i.Handle,
+		&i.IsModerator,
+		&i.SuspendedAt,
+		&i.ExpandContentWarnings,
+		&i.ShowSensitiveMedia,
+		&i.FollowerCount,
+		&i.FollowingCount,
+		&i.Protected,
+		&i.DmPolicy,
 	)
 	return i, err
 }
@@ -11,12 +11,198 @@ import (
 	"github.com/google/uuid"
 )
 
+type Block struct {
+	BlockerID uuid.UUID
+	BlockedID uuid.UUID
+	CreatedAt time.Time
+}
+
+type Bookmark struct {
+	UserID    uuid.UUID
+	ChirpID   uuid.UUID
+	FolderID  uuid.NullUUID
+	CreatedAt time.Time
+}
+
+type BookmarkFolder struct {
+	ID        uuid.UUID
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	UserID    uuid.UUID
+	Name      string
+}
+
 type Chirp struct {
+	ID                   uuid.UUID
+	CreatedAt            time.Time
+	UpdatedAt            time.Time
+	Body                 string
+	UserID               uuid.UUID
+	LikeCount            int32
+	PublishAt            sql.NullTime
+	DeletedAt            sql.NullTime
+	Visibility           string
+	HiddenAt             sql.NullTime
+	PinnedAt             sql.NullTime
+	ContentWarning       string
+	ContentWarningForced bool
+	InReplyToID          uuid.NullUUID
+}
+
+type ChirpFlag struct {
+	ID        uuid.UUID
+	ChirpID   uuid.UUID
+	CreatedAt time.Time
+	Filter    string
+	Reason    string
+}
+
+type ChirpHashtag struct {
+	ChirpID   uuid.UUID
+	Tag       string
+	CreatedAt time.Time
+}
+
+type ChirpMention struct {
+	ChirpID     uuid.UUID
+	UserID      uuid.UUID
+	StartOffset int32
+	EndOffset   int32
+}
+
+type ChirpStatsHourly struct {
+	ChirpID     uuid.UUID
+	UserID      uuid.UUID
+	Hour        time.Time
+	Impressions int64
+	Views       int64
+	Likes       int64
+	Replies     int64
+}
+
+type Conversation struct {
+	ID            uuid.UUID
+	CreatedAt     time.Time
+	CreatorID     uuid.NullUUID
+	IsGroup       bool
+	DirectKey     sql.NullString
+	LastMessageAt time.Time
+}
+
+type ConversationParticipant struct {
+	ConversationID uuid.UUID
+	UserID         uuid.UUID
+	JoinedAt       time.Time
+	LastReadAt     sql.NullTime
+	Muted          bool
+}
+
+type Draft struct {
 	ID        uuid.UUID
 	CreatedAt time.Time
 	UpdatedAt time.Time
+	UserID    uuid.UUID
 	Body      string
+	MediaIds  []uuid.UUID
+}
+
+type Follow struct {
+	FollowerID uuid.UUID
+	FolloweeID uuid.UUID
+	CreatedAt  time.Time
+}
+
+type FollowRequest struct {
+	RequesterID uuid.UUID
+	TargetID    uuid.UUID
+	CreatedAt   time.Time
+}
+
+type Like struct {
 	UserID    uuid.UUID
+	ChirpID   uuid.UUID
+	CreatedAt time.Time
+}
+
+type List struct {
+	ID              uuid.UUID
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+	OwnerID         uuid.UUID
+	Name            string
+	Visibility      string
+	MemberCount     int32
+	SubscriberCount int32
+}
+
+type ListMember struct {
+	ListID    uuid.UUID
+	UserID    uuid.UUID
+	CreatedAt time.Time
+}
+
+type ListSubscription struct {
+	ListID    uuid.UUID
+	UserID    uuid.UUID
+	CreatedAt time.Time
+}
+
+type MediaFile struct {
+	ID                uuid.UUID
+	CreatedAt         time.Time
+	UserID            uuid.UUID
+	ChirpID           uuid.NullUUID
+	MimeType          string
+	ThumbnailMimeType string
+	SizeBytes         int32
+	Width             int32
+	Height            int32
+	AltText           string
+	Sensitive         bool
+}
+
+type Message struct {
+	ID             uuid.UUID
+	ConversationID uuid.UUID
+	SenderID       uuid.UUID
+	CreatedAt      time.Time
+	Body           []byte
+}
+
+type ModerationWord struct {
+	ID        uuid.UUID
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	Word      string
+	Action    string
+	Reason    string
+}
+
+type Mute struct {
+	MuterID   uuid.UUID
+	MutedID   uuid.UUID
+	CreatedAt time.Time
+	ExpiresAt sql.NullTime
+}
+
+type Poll struct {
+	ChirpID   uuid.UUID
+	CreatedAt time.Time
+	ClosesAt  time.Time
+}
+
+type PollOption struct {
+	ChirpID   uuid.UUID
+	Position  int32
+	Label     string
+	VoteCount int32
+}
+
+type PollVote struct {
+	ChirpID   uuid.UUID
+	UserID    uuid.UUID
+	Position  int32
+	CreatedAt time.Time
 }
 
 type RefreshToken struct {
@@ -28,11 +214,51 @@ type RefreshToken struct {
 	RevokedAt sql.NullTime
 }
 
-type User struct {
+type Report struct {
 	ID             uuid.UUID
 	CreatedAt      time.Time
 	UpdatedAt      time.Time
-	Email          string
-	HashedPassword string
-	IsChirpyRed    sql.NullBool
+	ReporterID     uuid.UUID
+	ReportedUserID uuid.UUID
+	ChirpID        uuid.NullUUID
+	Reason         string
+	Details        string
+	Status         string
+	AssigneeID     uuid.NullUUID
+	Resolution     sql.NullString
+	ResolvedAt     sql.NullTime
+}
+
+type ReportDecision struct {
+	ID          uuid.UUID
+	CreatedAt   time.Time
+	ReportID    uuid.UUID
+	ModeratorID uuid.NullUUID
+	Action      string
+	Note        string
+}
+
+type TimelineEntry struct {
+	UserID    uuid.UUID
+	ChirpID   uuid.UUID
+	AuthorID  uuid.UUID
+	CreatedAt time.Time
+}
+
+type User struct {
+	ID                    uuid.UUID
+	CreatedAt             time.Time
+	UpdatedAt             time.Time
+	Email                 string
+	HashedPassword        string
+	IsChirpyRed           sql.NullBool
+	Handle                sql.NullString
+	IsModerator           bool
+	SuspendedAt           sql.NullTime
+	ExpandContentWarnings bool
+	ShowSensitiveMedia    bool
+	FollowerCount         int32
+	FollowingCount        int32
+	Protected             bool
+	DmPolicy              string
 }
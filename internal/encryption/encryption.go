@@ -0,0 +1,80 @@
+// Package encryption seals small payloads, such as direct message bodies,
+// with a key held by the server so that they are not stored in the clear.
+package encryption
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+)
+
+// KeySize is the length of a key in bytes. Keys are used for AES-256-GCM.
+const KeySize = 32
+
+// ErrDecrypt is returned by Open when a sealed payload can't be decrypted,
+// because it was sealed with another key or associated data, or has been
+// altered.
+var ErrDecrypt = errors.New("encryption: couldn't decrypt payload")
+
+// Box seals and opens payloads with a single key. It is safe for concurrent
+// use.
+type Box struct {
+	aead cipher.AEAD
+}
+
+// New returns a Box for a KeySize byte key.
+func New(key []byte) (*Box, error) {
+	if len(key) != KeySize {
+		return nil, fmt.Errorf("encryption: key must be %d bytes, got %d", KeySize, len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &Box{aead: aead}, nil
+}
+
+// ParseKey decodes a base64 encoded key, such as one generated with
+// `openssl rand -base64 32`.
+func ParseKey(s string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("encryption: key must be base64: %w", err)
+	}
+	if len(key) != KeySize {
+		return nil, fmt.Errorf("encryption: key must be %d bytes, got %d", KeySize, len(key))
+	}
+	return key, nil
+}
+
+// Seal encrypts plaintext with a fresh random nonce, which is prepended to
+// the result. additionalData isn't stored, but the same bytes must be given
+// to Open; binding a payload to the row it is stored in stops it from being
+// moved to another.
+func (b *Box) Seal(plaintext, additionalData []byte) ([]byte, error) {
+	nonce := make([]byte, b.aead.NonceSize(), b.aead.NonceSize()+len(plaintext)+b.aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return b.aead.Seal(nonce, nonce, plaintext, additionalData), nil
+}
+
+// Open decrypts a payload returned by Seal.
+func (b *Box) Open(sealed, additionalData []byte) ([]byte, error) {
+	if len(sealed) < b.aead.NonceSize() {
+		return nil, ErrDecrypt
+	}
+	nonce, ciphertext := sealed[:b.aead.NonceSize()], sealed[b.aead.NonceSize():]
+	plaintext, err := b.aead.Open(nil, nonce, ciphertext, additionalData)
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+	return plaintext, nil
+}
@@ -0,0 +1,103 @@
+package encryption
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"testing"
+)
+
+func testBox(t *testing.T, fill byte) *Box {
+	t.Helper()
+	box, err := New(bytes.Repeat([]byte{fill}, KeySize))
+	if err != nil {
+		t.Fatalf("New returned error: %v", err)
+	}
+	return box
+}
+
+func TestSealOpenRoundTrip(t *testing.T) {
+	box := testBox(t, 1)
+	plaintext := []byte("see you at the gopher meetup")
+	ad := []byte("conversation/message")
+
+	sealed, err := box.Seal(plaintext, ad)
+	if err != nil {
+		t.Fatalf("Seal returned error: %v", err)
+	}
+	if bytes.Contains(sealed, plaintext) {
+		t.Errorf("sealed payload contains the plaintext")
+	}
+
+	opened, err := box.Open(sealed, ad)
+	if err != nil {
+		t.Fatalf("Open returned error: %v", err)
+	}
+	if !bytes.Equal(opened, plaintext) {
+		t.Errorf("Open = %q, want %q", opened, plaintext)
+	}
+}
+
+func TestSealUsesFreshNonces(t *testing.T) {
+	box := testBox(t, 1)
+	first, err := box.Seal([]byte("hi"), nil)
+	if err != nil {
+		t.Fatalf("Seal returned error: %v", err)
+	}
+	second, err := box.Seal([]byte("hi"), nil)
+	if err != nil {
+		t.Fatalf("Seal returned error: %v", err)
+	}
+	if bytes.Equal(first, second) {
+		t.Errorf("sealing the same plaintext twice gave the same payload")
+	}
+}
+
+func TestOpenRejects(t *testing.T) {
+	box := testBox(t, 1)
+	sealed, err := box.Seal([]byte("hello"), []byte("a"))
+	if err != nil {
+		t.Fatalf("Seal returned error: %v", err)
+	}
+	tampered := bytes.Clone(sealed)
+	tampered[len(tampered)-1] ^= 1
+
+	tests := []struct {
+		name   string
+		box    *Box
+		sealed []byte
+		ad     []byte
+	}{
+		{"other key", testBox(t, 2), sealed, []byte("a")},
+		{"other associated data", box, sealed, []byte("b")},
+		{"tampered", box, tampered, []byte("a")},
+		{"truncated", box, sealed[:4], []byte("a")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.box.Open(tt.sealed, tt.ad); !errors.Is(err, ErrDecrypt) {
+				t.Errorf("Open error = %v, want ErrDecrypt", err)
+			}
+		})
+	}
+}
+
+func TestParseKey(t *testing.T) {
+	key := bytes.Repeat([]byte{7}, KeySize)
+	got, err := ParseKey(base64.StdEncoding.EncodeToString(key))
+	if err != nil {
+		t.Fatalf("ParseKey returned error: %v", err)
+	}
+	if !bytes.Equal(got, key) {
+		t.Errorf("ParseKey = %x, want %x", got, key)
+	}
+
+	for _, s := range []string{"", "not base64!", base64.StdEncoding.EncodeToString([]byte("short"))} {
+		if _, err := ParseKey(s); err == nil {
+			t.Errorf("ParseKey(%q) returned no error", s)
+		}
+	}
+	if _, err := New([]byte("short")); err == nil {
+		t.Errorf("New accepted a short key")
+	}
+}
@@ -0,0 +1,162 @@
+package entities
+
+import (
+	"strings"
+	"unicode"
+)
+
+// MaxHandleLength is the longest handle a user may register.
+const MaxHandleLength = 15
+
+// Hashtag is a #tag found in a chirp body. Start and End are rune offsets
+// into the body, covering the leading '#' through the end of the tag.
+type Hashtag struct {
+	Tag   string
+	Start int
+	End   int
+}
+
+// Mention is an @handle found in a chirp body. Start and End are rune
+// offsets covering the leading '@' through the end of the handle.
+type Mention struct {
+	Handle string
+	Start  int
+	End    int
+}
+
+// URL is an http or https link found in a chirp body. Start and End are rune
+// offsets into the body.
+type URL struct {
+	URL   string
+	Start int
+	End   int
+}
+
+// ParseHashtags returns the hashtags in body in the order they appear. Tags
+// are normalized with NormalizeHashtag. A '#' only starts a tag when it is not
+// preceded by a word character, and a tag must contain at least one letter,
+// so "issue#4" and "#1" are not hashtags.
+func ParseHashtags(body string) []Hashtag {
+	runes := []rune(body)
+	var hashtags []Hashtag
+	for i := 0; i < len(runes); i++ {
+		if runes[i] != '#' || (i > 0 && isWordRune(runes[i-1])) {
+			continue
+		}
+		end := i + 1
+		hasLetter := false
+		for end < len(runes) && isWordRune(runes[end]) {
+			if unicode.IsLetter(runes[end]) {
+				hasLetter = true
+			}
+			end++
+		}
+		if !hasLetter {
+			continue
+		}
+		hashtags = append(hashtags, Hashtag{
+			Tag:   NormalizeHashtag(string(runes[i+1 : end])),
+			Start: i,
+			End:   end,
+		})
+		i = end - 1
+	}
+	return hashtags
+}
+
+// ParseMentions returns the @handle mentions in body in the order they
+// appear, with handles normalized by NormalizeHandle. As with hashtags, an
+// '@' preceded by a word character (such as in an email address) does not
+// start a mention.
+func ParseMentions(body string) []Mention {
+	runes := []rune(body)
+	var mentions []Mention
+	for i := 0; i < len(runes); i++ {
+		if runes[i] != '@' || (i > 0 && isWordRune(runes[i-1])) {
+			continue
+		}
+		end := i + 1
+		for end < len(runes) && isHandleRune(runes[end]) {
+			end++
+		}
+		if end == i+1 || end-i-1 > MaxHandleLength {
+			continue
+		}
+		mentions = append(mentions, Mention{
+			Handle: NormalizeHandle(string(runes[i+1 : end])),
+			Start:  i,
+			End:    end,
+		})
+		i = end - 1
+	}
+	return mentions
+}
+
+// ParseURLs returns the http and https links in body. A link runs until the
+// next whitespace, minus any trailing punctuation that more likely ends the
+// sentence than the link.
+func ParseURLs(body string) []URL {
+	runes := []rune(body)
+	var urls []URL
+	for i := 0; i < len(runes); i++ {
+		if i > 0 && !unicode.IsSpace(runes[i-1]) && runes[i-1] != '(' {
+			continue
+		}
+		rest := string(runes[i:])
+		if !strings.HasPrefix(rest, "http://") && !strings.HasPrefix(rest, "https://") {
+			continue
+		}
+		end := i
+		for end < len(runes) && !unicode.IsSpace(runes[end]) {
+			end++
+		}
+		for end > i && strings.ContainsRune(".,!?;:)'\"", runes[end-1]) {
+			end--
+		}
+		url := string(runes[i:end])
+		if url == "http://" || url == "https://" {
+			continue
+		}
+		urls = append(urls, URL{
+			URL:   url,
+			Start: i,
+			End:   end,
+		})
+		i = end - 1
+	}
+	return urls
+}
+
+// NormalizeHashtag returns the stored form of a tag: lower case, without a
+// leading '#'.
+func NormalizeHashtag(tag string) string {
+	return strings.ToLower(strings.TrimPrefix(tag, "#"))
+}
+
+// NormalizeHandle returns the stored form of a handle: lower case, without a
+// leading '@'.
+func NormalizeHandle(handle string) string {
+	return strings.ToLower(strings.TrimPrefix(handle, "@"))
+}
+
+// ValidHandle reports whether handle may be registered. Handles are 1 to
+// MaxHandleLength ASCII letters, digits or underscores.
+func ValidHandle(handle string) bool {
+	if handle == "" || len(handle) > MaxHandleLength {
+		return false
+	}
+	for _, r := range handle {
+		if !isHandleRune(r) {
+			return false
+		}
+	}
+	return true
+}
+
+func isWordRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+func isHandleRune(r rune) bool {
+	return r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9')
+}
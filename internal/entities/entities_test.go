@@ -0,0 +1,58 @@
+package entities
+
+import (
+	"testing"
+)
+
+func TestParseHashtags(t *testing.T) {
+	hashtags := ParseHashtags("Loving #GoLang and #café_2025, not issue#4 or #1!")
+	if len(hashtags) != 2 {
+		t.Fatalf("Expected 2 hashtags, got %d: %v", len(hashtags), hashtags)
+	}
+
+	if hashtags[0].Tag != "golang" || hashtags[0].Start != 7 || hashtags[0].End != 14 {
+		t.Errorf("Unexpected first hashtag: %+v", hashtags[0])
+	}
+
+	// Offsets count runes, not bytes
+	if hashtags[1].Tag != "café_2025" || hashtags[1].Start != 19 || hashtags[1].End != 29 {
+		t.Errorf("Unexpected second hashtag: %+v", hashtags[1])
+	}
+}
+
+func TestParseHashtagsNone(t *testing.T) {
+	hashtags := ParseHashtags("No tags here # at all")
+	if len(hashtags) != 0 {
+		t.Fatalf("Expected no hashtags, got %v", hashtags)
+	}
+}
+
+func TestParseMentions(t *testing.T) {
+	mentions := ParseMentions("@Alice meet @bob_2, not me@example.com")
+	if len(mentions) != 2 {
+		t.Fatalf("Expected 2 mentions, got %d: %v", len(mentions), mentions)
+	}
+
+	if mentions[0].Handle != "alice" || mentions[0].Start != 0 || mentions[0].End != 6 {
+		t.Errorf("Unexpected first mention: %+v", mentions[0])
+	}
+
+	if mentions[1].Handle != "bob_2" || mentions[1].Start != 12 || mentions[1].End != 18 {
+		t.Errorf("Unexpected second mention: %+v", mentions[1])
+	}
+}
+
+func TestParseURLs(t *testing.T) {
+	urls := ParseURLs("Read https://go.dev/doc. (or http://example.com)")
+	if len(urls) != 2 {
+		t.Fatalf("Expected 2 urls, got %d: %v", len(urls), urls)
+	}
+
+	if urls[0].URL != "https://go.dev/doc" || urls[0].Start != 5 || urls[0].End != 23 {
+		t.Errorf("Unexpected first url: %+v", urls[0])
+	}
+
+	if urls[1].URL != "http://example.com" {
+		t.Errorf("Unexpected second url: %+v", urls[1])
+	}
+}
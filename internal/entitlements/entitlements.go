@@ -0,0 +1,141 @@
+package entitlements
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Tier is a subscription level. Every user is on exactly one tier.
+type Tier string
+
+const (
+	Free Tier = "free"
+	Red  Tier = "red"
+)
+
+// TierFor returns the tier for a user from their Chirpy Red flag.
+func TierFor(isChirpyRed bool) Tier {
+	if isChirpyRed {
+		return Red
+	}
+	return Free
+}
+
+// Entitlements are the limits that apply to a user on a given tier.
+type Entitlements struct {
+	// MaxChirpLength is the longest chirp body in characters.
+	MaxChirpLength int `json:"max_chirp_length"`
+	// MaxChirpMedia is the number of media files that can be attached to a
+	// chirp.
+	MaxChirpMedia int `json:"max_chirp_media"`
+	// EditWindow is how long after publishing a chirp can still be edited.
+	EditWindow Duration `json:"edit_window"`
+	// ChirpsPerHour is how many chirps can be posted in any hour.
+	ChirpsPerHour int `json:"chirps_per_hour"`
+	// AnalyticsDays is how far back chirp analytics can be viewed.
+	AnalyticsDays int `json:"analytics_days"`
+	// DetailedAnalytics allows hourly analytics and per-chirp breakdowns.
+	DetailedAnalytics bool `json:"detailed_analytics"`
+}
+
+// Duration is a time.Duration written in JSON as a string such as "5m".
+type Duration time.Duration
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("duration must be a string such as \"5m\": %w", err)
+	}
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// Config maps each tier to its entitlements.
+type Config map[Tier]Entitlements
+
+// Default returns the built-in tier mapping.
+func Default() Config {
+	return Config{
+		Free: {
+			MaxChirpLength: 140,
+			MaxChirpMedia:  4,
+			EditWindow:     Duration(5 * time.Minute),
+			ChirpsPerHour:  30,
+			AnalyticsDays:  7,
+		},
+		Red: {
+			MaxChirpLength:    500,
+			MaxChirpMedia:     8,
+			EditWindow:        Duration(time.Hour),
+			ChirpsPerHour:     300,
+			AnalyticsDays:     90,
+			DetailedAnalytics: true,
+		},
+	}
+}
+
+// Load reads a JSON tier mapping from r, such as
+//
+//	{"red": {"max_chirp_length": 1000, "edit_window": "2h"}}
+//
+// Tiers and fields that aren't given keep their default values.
+func Load(r io.Reader) (Config, error) {
+	overrides := map[Tier]json.RawMessage{}
+	if err := json.NewDecoder(r).Decode(&overrides); err != nil {
+		return nil, err
+	}
+
+	config := Default()
+	for tier, raw := range overrides {
+		ent, ok := config[tier]
+		if !ok {
+			return nil, fmt.Errorf("unknown tier %q", tier)
+		}
+		if err := json.Unmarshal(raw, &ent); err != nil {
+			return nil, fmt.Errorf("tier %q: %w", tier, err)
+		}
+		if err := ent.validate(); err != nil {
+			return nil, fmt.Errorf("tier %q: %w", tier, err)
+		}
+		config[tier] = ent
+	}
+	return config, nil
+}
+
+// For returns the entitlements for tier, falling back to the free tier.
+func (c Config) For(tier Tier) Entitlements {
+	if ent, ok := c[tier]; ok {
+		return ent
+	}
+	return c[Free]
+}
+
+func (e Entitlements) validate() error {
+	if e.MaxChirpLength <= 0 {
+		return errors.New("max_chirp_length must be positive")
+	}
+	if e.MaxChirpMedia < 0 {
+		return errors.New("max_chirp_media can't be negative")
+	}
+	if e.EditWindow < 0 {
+		return errors.New("edit_window can't be negative")
+	}
+	if e.ChirpsPerHour <= 0 {
+		return errors.New("chirps_per_hour must be positive")
+	}
+	if e.AnalyticsDays <= 0 {
+		return errors.New("analytics_days must be positive")
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package entitlements
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTierFor(t *testing.T) {
+	if got := TierFor(false); got != Free {
+		t.Errorf("TierFor(false) = %q, want %q", got, Free)
+	}
+	if got := TierFor(true); got != Red {
+		t.Errorf("TierFor(true) = %q, want %q", got, Red)
+	}
+}
+
+func TestLoadOverridesDefaults(t *testing.T) {
+	config, err := Load(strings.NewReader(`{"red": {"max_chirp_length": 1000, "edit_window": "2h"}}`))
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+
+	red := config.For(Red)
+	if red.MaxChirpLength != 1000 {
+		t.Errorf("red MaxChirpLength = %d, want 1000", red.MaxChirpLength)
+	}
+	if time.Duration(red.EditWindow) != 2*time.Hour {
+		t.Errorf("red EditWindow = %v, want 2h", time.Duration(red.EditWindow))
+	}
+	if red.MaxChirpMedia != Default()[Red].MaxChirpMedia {
+		t.Errorf("red MaxChirpMedia = %d, want default %d", red.MaxChirpMedia, Default()[Red].MaxChirpMedia)
+	}
+	if config.For(Free) != Default()[Free] {
+		t.Errorf("free tier changed: got %+v", config.For(Free))
+	}
+}
+
+func TestLoadRejectsInvalidConfig(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"unknown tier", `{"gold": {"max_chirp_length": 1000}}`},
+		{"bad duration", `{"free": {"edit_window": "soon"}}`},
+		{"numeric duration", `{"free": {"edit_window": 300}}`},
+		{"zero length", `{"free": {"max_chirp_length": 0}}`},
+		{"zero analytics days", `{"red": {"analytics_days": 0}}`},
+		{"not json", `max_chirp_length=1000`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Load(strings.NewReader(tt.input)); err == nil {
+				t.Errorf("Load(%s) returned no error", tt.input)
+			}
+		})
+	}
+}
+
+func TestForFallsBackToFree(t *testing.T) {
+	config := Default()
+	if got := config.For("unknown"); got != config[Free] {
+		t.Errorf("For(unknown) = %+v, want free tier", got)
+	}
+}
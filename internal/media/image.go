@@ -0,0 +1,300 @@
+package media
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+)
+
+const (
+	// MaxUploadSize is the largest file accepted for upload, in bytes.
+	MaxUploadSize = 5 << 20
+	// ThumbnailSize is the longest side of a generated thumbnail, in pixels.
+	ThumbnailSize = 320
+	// MaxPixels is the largest width times height accepted for an image.
+	// Compressed images can declare far larger dimensions than their file
+	// size suggests, and decoding allocates for the declared size.
+	MaxPixels = 40_000_000
+	// MaxGIFFrames is the most frames accepted in an animated GIF.
+	MaxGIFFrames = 200
+	// MaxGIFPixels is the largest total of width times height over all of a
+	// GIF's frames, which are each decoded into their own image.
+	MaxGIFPixels = 100_000_000
+)
+
+var (
+	// ErrUnsupportedType is returned by Process for anything other than a
+	// JPEG, PNG or GIF image.
+	ErrUnsupportedType = errors.New("unsupported media type")
+	// ErrTooLarge is returned by Process for images whose dimensions or
+	// frame count are over the limits above.
+	ErrTooLarge = errors.New("image too large")
+)
+
+// Image is an uploaded image that has been cleaned and thumbnailed.
+type Image struct {
+	MIMEType          string
+	Data              []byte
+	Width             int
+	Height            int
+	ThumbnailMIMEType string
+	Thumbnail         []byte
+}
+
+// Process checks that data is an image we accept, judged by its contents
+// rather than any client-supplied type, and re-encodes it. Re-encoding drops
+// EXIF and other metadata, so a JPEG's EXIF orientation is applied to the
+// pixels first to keep the image the right way up.
+func Process(data []byte) (Image, error) {
+	mimeType := http.DetectContentType(data)
+	switch mimeType {
+	case "image/jpeg", "image/png", "image/gif":
+	default:
+		return Image{}, ErrUnsupportedType
+	}
+
+	// Check the declared size before decoding allocates for it
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return Image{}, err
+	}
+	pixels := int64(config.Width) * int64(config.Height)
+	if pixels > MaxPixels {
+		return Image{}, ErrTooLarge
+	}
+	if mimeType == "image/gif" {
+		frames := int64(gifFrameCount(data))
+		if frames > MaxGIFFrames || frames*pixels > MaxGIFPixels {
+			return Image{}, ErrTooLarge
+		}
+	}
+
+	var img image.Image
+	var out bytes.Buffer
+	switch mimeType {
+	case "image/jpeg":
+		decoded, err := jpeg.Decode(bytes.NewReader(data))
+		if err != nil {
+			return Image{}, err
+		}
+		img = orient(decoded, jpegOrientation(data))
+		if err := jpeg.Encode(&out, img, &jpeg.Options{Quality: 90}); err != nil {
+			return Image{}, err
+		}
+	case "image/png":
+		decoded, err := png.Decode(bytes.NewReader(data))
+		if err != nil {
+			return Image{}, err
+		}
+		img = decoded
+		if err := png.Encode(&out, img); err != nil {
+			return Image{}, err
+		}
+	case "image/gif":
+		decoded, err := gif.DecodeAll(bytes.NewReader(data))
+		if err != nil {
+			return Image{}, err
+		}
+		img = decoded.Image[0]
+		if err := gif.EncodeAll(&out, decoded); err != nil {
+			return Image{}, err
+		}
+	}
+
+	thumb := thumbnail(img, ThumbnailSize)
+	var thumbOut bytes.Buffer
+	thumbMIMEType := "image/png"
+	if mimeType == "image/jpeg" {
+		thumbMIMEType = "image/jpeg"
+		if err := jpeg.Encode(&thumbOut, thumb, &jpeg.Options{Quality: 80}); err != nil {
+			return Image{}, err
+		}
+	} else if err := png.Encode(&thumbOut, thumb); err != nil {
+		return Image{}, err
+	}
+
+	return Image{
+		MIMEType:          mimeType,
+		Data:              out.Bytes(),
+		Width:             img.Bounds().Dx(),
+		Height:            img.Bounds().Dy(),
+		ThumbnailMIMEType: thumbMIMEType,
+		Thumbnail:         thumbOut.Bytes(),
+	}, nil
+}
+
+// gifFrameCount counts the frames in a GIF by walking its blocks, without
+// decoding any of them. It stops at anything it doesn't recognise, leaving
+// malformed files for the decoder to reject.
+func gifFrameCount(data []byte) int {
+	// Header and logical screen descriptor, then the global colour table
+	if len(data) < 13 {
+		return 0
+	}
+	i := 13
+	if data[10]&0x80 != 0 {
+		i += 3 << (data[10]&0x07 + 1)
+	}
+
+	frames := 0
+	for i < len(data) {
+		switch data[i] {
+		case 0x21:
+			// Extension introducer and label
+			i += 2
+		case 0x2C:
+			// Image descriptor, local colour table and LZW minimum code size
+			if i+10 > len(data) {
+				return frames
+			}
+			flags := data[i+9]
+			i += 10
+			if flags&0x80 != 0 {
+				i += 3 << (flags&0x07 + 1)
+			}
+			i++
+			frames++
+		default:
+			// Trailer, or something the decoder will reject
+			return frames
+		}
+		// Data sub-blocks, ended by an empty one
+		for i < len(data) && data[i] != 0 {
+			i += int(data[i]) + 1
+		}
+		i++
+	}
+	return frames
+}
+
+// thumbnail scales img down so its longest side is at most size pixels,
+// averaging each block of source pixels into one destination pixel. Images
+// that already fit are copied unchanged.
+func thumbnail(img image.Image, size int) image.Image {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	tw, th := w, h
+	if w > size || h > size {
+		if w >= h {
+			tw, th = size, max(1, h*size/w)
+		} else {
+			tw, th = max(1, w*size/h), size
+		}
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, tw, th))
+	for y := 0; y < th; y++ {
+		y0, y1 := b.Min.Y+y*h/th, b.Min.Y+max((y+1)*h/th, y*h/th+1)
+		for x := 0; x < tw; x++ {
+			x0, x1 := b.Min.X+x*w/tw, b.Min.X+max((x+1)*w/tw, x*w/tw+1)
+			var r, g, bl, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					cr, cg, cb, ca := img.At(sx, sy).RGBA()
+					r, g, bl, a = r+uint64(cr), g+uint64(cg), bl+uint64(cb), a+uint64(ca)
+					n++
+				}
+			}
+			dst.SetRGBA(x, y, color.RGBA{
+				R: uint8(r / n >> 8),
+				G: uint8(g / n >> 8),
+				B: uint8(bl / n >> 8),
+				A: uint8(a / n >> 8),
+			})
+		}
+	}
+	return dst
+}
+
+// orient returns img transformed according to an EXIF orientation value
+// (1-8) so that it displays upright without the orientation tag.
+func orient(img image.Image, orientation int) image.Image {
+	if orientation < 2 || orientation > 8 {
+		return img
+	}
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	dw, dh := w, h
+	if orientation >= 5 {
+		dw, dh = h, w
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < dh; y++ {
+		for x := 0; x < dw; x++ {
+			var sx, sy int
+			switch orientation {
+			case 2:
+				sx, sy = w-1-x, y
+			case 3:
+				sx, sy = w-1-x, h-1-y
+			case 4:
+				sx, sy = x, h-1-y
+			case 5:
+				sx, sy = y, x
+			case 6:
+				sx, sy = y, h-1-x
+			case 7:
+				sx, sy = w-1-y, h-1-x
+			case 8:
+				sx, sy = w-1-y, x
+			}
+			dst.Set(x, y, img.At(b.Min.X+sx, b.Min.Y+sy))
+		}
+	}
+	return dst
+}
+
+// jpegOrientation reads the EXIF orientation tag from a JPEG, returning 1
+// (upright) if there isn't one.
+func jpegOrientation(data []byte) int {
+	const orientationTag = 0x0112
+	for i := 2; i+4 <= len(data); {
+		if data[i] != 0xFF {
+			return 1
+		}
+		marker := data[i+1]
+		length := int(binary.BigEndian.Uint16(data[i+2:]))
+		if marker == 0xDA || length < 2 || i+2+length > len(data) {
+			return 1
+		}
+		segment := data[i+4 : i+2+length]
+		i += 2 + length
+
+		if marker != 0xE1 || len(segment) < 14 || string(segment[:6]) != "Exif\x00\x00" {
+			continue
+		}
+		tiff := segment[6:]
+		var order binary.ByteOrder
+		switch string(tiff[:2]) {
+		case "II":
+			order = binary.LittleEndian
+		case "MM":
+			order = binary.BigEndian
+		default:
+			return 1
+		}
+		ifd := int(order.Uint32(tiff[4:]))
+		if ifd+2 > len(tiff) {
+			return 1
+		}
+		count := int(order.Uint16(tiff[ifd:]))
+		for e := 0; e < count; e++ {
+			entry := ifd + 2 + e*12
+			if entry+12 > len(tiff) {
+				return 1
+			}
+			if order.Uint16(tiff[entry:]) == orientationTag {
+				return int(order.Uint16(tiff[entry+8:]))
+			}
+		}
+		return 1
+	}
+	return 1
+}
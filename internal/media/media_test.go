@@ -0,0 +1,142 @@
+package media
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"io"
+	"testing"
+)
+
+func TestLocalStore(t *testing.T) {
+	store, err := NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+
+	ctx := context.Background()
+	if err := store.Put(ctx, "blob", bytes.NewReader([]byte("hello"))); err != nil {
+		t.Fatalf("Failed to put blob: %v", err)
+	}
+
+	r, err := store.Get(ctx, "blob")
+	if err != nil {
+		t.Fatalf("Failed to get blob: %v", err)
+	}
+	data, _ := io.ReadAll(r)
+	r.Close()
+	if string(data) != "hello" {
+		t.Errorf("Expected blob contents %q, got %q", "hello", data)
+	}
+
+	if err := store.Delete(ctx, "blob"); err != nil {
+		t.Fatalf("Failed to delete blob: %v", err)
+	}
+	if _, err := store.Get(ctx, "blob"); err != ErrNotFound {
+		t.Errorf("Expected ErrNotFound after delete, got %v", err)
+	}
+
+	if err := store.Put(ctx, "../escape", bytes.NewReader(nil)); err == nil {
+		t.Errorf("Expected error for key outside the store directory")
+	}
+}
+
+func TestProcessStripsEXIFAndRotates(t *testing.T) {
+	// A 40x20 image tagged with orientation 6 (rotate 90 degrees clockwise)
+	img := image.NewRGBA(image.Rect(0, 0, 40, 20))
+	for y := 0; y < 20; y++ {
+		for x := 0; x < 40; x++ {
+			img.Set(x, y, color.RGBA{R: 200, A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, nil); err != nil {
+		t.Fatalf("Failed to encode test image: %v", err)
+	}
+	exif := []byte{
+		0xFF, 0xE1, 0x00, 0x22,
+		'E', 'x', 'i', 'f', 0, 0,
+		'M', 'M', 0x00, 0x2A, 0x00, 0x00, 0x00, 0x08,
+		0x00, 0x01,
+		0x01, 0x12, 0x00, 0x03, 0x00, 0x00, 0x00, 0x01, 0x00, 0x06, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x00,
+	}
+	data := append(append(append([]byte{}, buf.Bytes()[:2]...), exif...), buf.Bytes()[2:]...)
+
+	processed, err := Process(data)
+	if err != nil {
+		t.Fatalf("Failed to process image: %v", err)
+	}
+
+	if processed.MIMEType != "image/jpeg" {
+		t.Errorf("Expected image/jpeg, got %s", processed.MIMEType)
+	}
+	if bytes.Contains(processed.Data, []byte("Exif")) {
+		t.Errorf("Expected EXIF data to be stripped")
+	}
+	if processed.Width != 20 || processed.Height != 40 {
+		t.Errorf("Expected rotated size 20x40, got %dx%d", processed.Width, processed.Height)
+	}
+	if len(processed.Thumbnail) == 0 {
+		t.Errorf("Expected a thumbnail")
+	}
+}
+
+func TestProcessRejectsNonImages(t *testing.T) {
+	_, err := Process([]byte("<html><body>not an image</body></html>"))
+	if err != ErrUnsupportedType {
+		t.Fatalf("Expected ErrUnsupportedType, got %v", err)
+	}
+}
+
+func TestProcessRejectsHugeDimensions(t *testing.T) {
+	// A PNG that is only a header declaring a 100000x100000 image
+	ihdr := make([]byte, 13)
+	binary.BigEndian.PutUint32(ihdr[0:], 100000)
+	binary.BigEndian.PutUint32(ihdr[4:], 100000)
+	ihdr[8], ihdr[9] = 8, 6
+	var data bytes.Buffer
+	data.WriteString("\x89PNG\r\n\x1a\n")
+	binary.Write(&data, binary.BigEndian, uint32(len(ihdr)))
+	chunk := append([]byte("IHDR"), ihdr...)
+	data.Write(chunk)
+	binary.Write(&data, binary.BigEndian, crc32.ChecksumIEEE(chunk))
+
+	_, err := Process(data.Bytes())
+	if err != ErrTooLarge {
+		t.Fatalf("Expected ErrTooLarge, got %v", err)
+	}
+}
+
+func TestProcessRejectsTooManyGIFFrames(t *testing.T) {
+	palette := color.Palette{color.Black, color.White}
+	anim := &gif.GIF{}
+	for i := 0; i < MaxGIFFrames+1; i++ {
+		anim.Image = append(anim.Image, image.NewPaletted(image.Rect(0, 0, 2, 2), palette))
+		anim.Delay = append(anim.Delay, 10)
+	}
+	var data bytes.Buffer
+	if err := gif.EncodeAll(&data, anim); err != nil {
+		t.Fatalf("Couldn't encode test GIF: %v", err)
+	}
+	if got := gifFrameCount(data.Bytes()); got != MaxGIFFrames+1 {
+		t.Fatalf("Expected %d frames, got %d", MaxGIFFrames+1, got)
+	}
+
+	_, err := Process(data.Bytes())
+	if err != ErrTooLarge {
+		t.Fatalf("Expected ErrTooLarge, got %v", err)
+	}
+}
+
+func TestThumbnailSize(t *testing.T) {
+	thumb := thumbnail(image.NewRGBA(image.Rect(0, 0, 1000, 500)), ThumbnailSize)
+	if thumb.Bounds().Dx() != ThumbnailSize || thumb.Bounds().Dy() != ThumbnailSize/2 {
+		t.Errorf("Expected %dx%d thumbnail, got %v", ThumbnailSize, ThumbnailSize/2, thumb.Bounds())
+	}
+}
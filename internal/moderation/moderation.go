@@ -0,0 +1,194 @@
+package moderation
+
+import (
+	"strings"
+)
+
+// Action is what happens to text that a filter matches.
+type Action string
+
+const (
+	// Mask replaces the matched word with asterisks.
+	Mask Action = "mask"
+	// Reject refuses the text outright, with a reason shown to the author.
+	Reject Action = "reject"
+	// Flag accepts the text but records it for a moderator to review.
+	Flag Action = "flag"
+)
+
+// Valid reports whether a is a known action.
+func (a Action) Valid() bool {
+	return a == Mask || a == Reject || a == Flag
+}
+
+// maskText is what masked words are replaced with.
+const maskText = "****"
+
+// Match is a problem a filter found in some text. Term is what the filter
+// matched on, and Start and End are byte offsets of the matched word.
+type Match struct {
+	Filter string
+	Action Action
+	Term   string
+	Reason string
+	Start  int
+	End    int
+}
+
+// Filter checks tokenized text. Filters are given both the raw text and its
+// tokens so they can work on whole words or on the text as a whole.
+type Filter interface {
+	Name() string
+	Check(text string, tokens []Token) []Match
+}
+
+// Term is an entry in a WordList.
+type Term struct {
+	Word   string
+	Reason string
+}
+
+// WordList is a Filter that matches words from a list, ignoring case,
+// accents, leetspeak and letters repeated to stretch a word out.
+type WordList struct {
+	name   string
+	action Action
+	terms  map[string]Term
+	// collapsed holds the terms by their Collapse form, for matching
+	// stretched words
+	collapsed map[string]Term
+}
+
+// NewWordList returns a filter that applies action to any of terms.
+func NewWordList(name string, action Action, terms []Term) *WordList {
+	w := &WordList{
+		name:      name,
+		action:    action,
+		terms:     make(map[string]Term, len(terms)),
+		collapsed: make(map[string]Term, len(terms)),
+	}
+	for _, term := range terms {
+		if key := Normalize(term.Word); key != "" {
+			w.terms[key] = term
+			if _, ok := w.collapsed[Collapse(key)]; !ok {
+				w.collapsed[Collapse(key)] = term
+			}
+		}
+	}
+	return w
+}
+
+func (w *WordList) Name() string {
+	return w.name
+}
+
+func (w *WordList) Check(text string, tokens []Token) []Match {
+	var matches []Match
+	for _, token := range tokens {
+		term, ok := w.terms[token.Normalized]
+		if !ok {
+			// Only words with repeated letters are matched collapsed, so
+			// that "butt" matches "buuutt" but not "but"
+			if collapsed := Collapse(token.Normalized); collapsed != token.Normalized {
+				term, ok = w.collapsed[collapsed]
+			}
+		}
+		if !ok {
+			continue
+		}
+		matches = append(matches, Match{
+			Filter: w.name,
+			Action: w.action,
+			Term:   term.Word,
+			Reason: term.Reason,
+			Start:  token.Start,
+			End:    token.End,
+		})
+	}
+	return matches
+}
+
+// Result is the outcome of moderating some text.
+type Result struct {
+	// Text is the input with masked words replaced.
+	Text string
+	// Rejection is the first Reject match, if any.
+	Rejection *Match
+	// Flags are the Flag matches, to be recorded for review.
+	Flags []Match
+}
+
+// Rejected reports whether the text was refused.
+func (r Result) Rejected() bool {
+	return r.Rejection != nil
+}
+
+// Pipeline runs text through a fixed set of filters. A Pipeline is never
+// modified once built, so it is safe for concurrent use; to change the
+// filters, build a new one.
+type Pipeline struct {
+	filters []Filter
+}
+
+// NewPipeline returns a pipeline that runs filters in order.
+func NewPipeline(filters ...Filter) *Pipeline {
+	return &Pipeline{filters: filters}
+}
+
+// Moderate runs text through every filter. The text is only tokenized once,
+// and a word matched by several filters is only masked once.
+func (p *Pipeline) Moderate(text string) Result {
+	result := Result{Text: text}
+	if p == nil {
+		return result
+	}
+
+	tokens := Tokenize(text)
+	var masks []Match
+	for _, filter := range p.filters {
+		for _, match := range filter.Check(text, tokens) {
+			switch match.Action {
+			case Reject:
+				if result.Rejection == nil {
+					m := match
+					result.Rejection = &m
+				}
+			case Flag:
+				result.Flags = append(result.Flags, match)
+			case Mask:
+				masks = append(masks, match)
+			}
+		}
+	}
+
+	result.Text = applyMasks(text, masks)
+	return result
+}
+
+// applyMasks replaces each masked span of text. Overlapping spans are
+// replaced once.
+func applyMasks(text string, masks []Match) string {
+	if len(masks) == 0 {
+		return text
+	}
+	covered := make([]bool, len(text))
+	for _, m := range masks {
+		for i := m.Start; i < m.End; i++ {
+			covered[i] = true
+		}
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(text); {
+		if !covered[i] {
+			b.WriteByte(text[i])
+			i++
+			continue
+		}
+		b.WriteString(maskText)
+		for i < len(text) && covered[i] {
+			i++
+		}
+	}
+	return b.String()
+}
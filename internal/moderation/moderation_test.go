@@ -0,0 +1,129 @@
+package moderation
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		input string
+		want  []string
+	}{
+		{"Hello, world!", []string{"Hello", "world"}},
+		{"fornax!", []string{"fornax"}},
+		{"(kerfuffle)...", []string{"kerfuffle"}},
+		{"sh@rbert is here", []string{"sh@rbert", "is", "here"}},
+		{"@handle mentioned", []string{"handle", "mentioned"}},
+		{"for\u200bnax", []string{"for\u200bnax"}},
+		{"héllo wörld", []string{"héllo", "wörld"}},
+		{"日本語 text", []string{"日本語", "text"}},
+		{"", nil},
+	}
+
+	for _, tt := range tests {
+		var got []string
+		for _, token := range Tokenize(tt.input) {
+			got = append(got, token.Text)
+			if tt.input[token.Start:token.End] != token.Text {
+				t.Errorf("Tokenize(%q): offsets %d-%d don't match %q", tt.input, token.Start, token.End, token.Text)
+			}
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Tokenize(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"Fornax", "fornax"},
+		{"F0RN4X", "fornax"},
+		{"sh@rb3rt", "sharbert"},
+		{"fooorrnaaax", "fooorrnaaax"},
+		{"fórnäx", "fornax"},
+		{"for\u200bnax", "fornax"},
+		{"k3rfuffl3", "kerfuffle"},
+	}
+
+	for _, tt := range tests {
+		if got := Normalize(tt.input); got != tt.want {
+			t.Errorf("Normalize(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestCollapse(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"fornax", "fornax"},
+		{"fooorrnaaax", "fornax"},
+		{"kerfuffle", "kerfufle"},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		if got := Collapse(tt.input); got != tt.want {
+			t.Errorf("Collapse(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestModerate(t *testing.T) {
+	pipeline := NewPipeline(
+		NewWordList("mask", Mask, []Term{{Word: "fornax"}, {Word: "kerfuffle"}}),
+		NewWordList("reject", Reject, []Term{{Word: "sharbert", Reason: "No sharberts allowed"}}),
+		NewWordList("flag", Flag, []Term{{Word: "suspicious"}}),
+	)
+
+	tests := []struct {
+		name      string
+		input     string
+		wantText  string
+		wantFlags int
+		rejected  bool
+	}{
+		{"clean", "This is a clean chirp", "This is a clean chirp", 0, false},
+		{"punctuation", "What a fornax!", "What a ****!", 0, false},
+		{"leetspeak", "Such a K3RFUFFL3 today", "Such a **** today", 0, false},
+		{"several", "fornax and kerfuffle", "**** and ****", 0, false},
+		{"substring", "fornaxes are fine", "fornaxes are fine", 0, false},
+		{"stretched", "What a fooorrnaaax", "What a ****", 0, false},
+		{"stretched double", "Such a kerfuuufflle", "Such a ****", 0, false},
+		{"collapsed double", "A kerfufle is fine", "A kerfufle is fine", 0, false},
+		{"flag", "Looks suspicious to me", "Looks suspicious to me", 1, false},
+		{"reject", "I love sh@rbert", "I love sh@rbert", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := pipeline.Moderate(tt.input)
+			if result.Text != tt.wantText {
+				t.Errorf("Text = %q, want %q", result.Text, tt.wantText)
+			}
+			if len(result.Flags) != tt.wantFlags {
+				t.Errorf("got %d flags, want %d", len(result.Flags), tt.wantFlags)
+			}
+			if result.Rejected() != tt.rejected {
+				t.Errorf("Rejected() = %v, want %v", result.Rejected(), tt.rejected)
+			}
+		})
+	}
+
+	result := pipeline.Moderate("sharbert")
+	if result.Rejection.Reason != "No sharberts allowed" {
+		t.Errorf("Rejection reason = %q", result.Rejection.Reason)
+	}
+}
+
+func TestNilPipeline(t *testing.T) {
+	var pipeline *Pipeline
+	if got := pipeline.Moderate("fornax"); got.Text != "fornax" || got.Rejected() {
+		t.Errorf("nil pipeline changed text: %+v", got)
+	}
+}
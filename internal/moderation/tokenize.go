@@ -0,0 +1,136 @@
+package moderation
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Token is a word found in moderated text. Start and End are byte offsets
+// into the text, and Normalized is the form filters compare against.
+type Token struct {
+	Text       string
+	Normalized string
+	Start      int
+	End        int
+}
+
+// leetspeak maps the symbols and digits commonly swapped in for letters.
+var leetspeak = map[rune]rune{
+	'0': 'o',
+	'1': 'i',
+	'3': 'e',
+	'4': 'a',
+	'5': 's',
+	'7': 't',
+	'8': 'b',
+	'@': 'a',
+	'$': 's',
+	'!': 'i',
+	'|': 'l',
+	'+': 't',
+}
+
+// accents folds common accented Latin letters to their base letter, so that
+// "fórnax" is treated like "fornax".
+var accents = map[rune]rune{}
+
+func init() {
+	for base, accented := range map[rune]string{
+		'a': "àáâãäåāăą",
+		'c': "çćĉċč",
+		'e': "èéêëēĕėęě",
+		'i': "ìíîïĩīĭįı",
+		'n': "ñńņňŉ",
+		'o': "òóôõöøōŏő",
+		's': "śŝşš",
+		'u': "ùúûüũūŭůűų",
+		'y': "ýÿŷ",
+		'z': "źżž",
+	} {
+		for _, r := range accented {
+			accents[r] = base
+		}
+	}
+}
+
+// Tokenize splits text into words. A word is a run of letters and digits,
+// along with any leetspeak symbols and invisible formatting characters
+// between them, so "sh@rbert" and "for\u200bnax" are single words while the
+// punctuation in "fornax!" and the @ of a mention are not part of the word.
+func Tokenize(text string) []Token {
+	var tokens []Token
+	start := -1
+	lastWordEnd := -1
+
+	flush := func() {
+		if start >= 0 && lastWordEnd > start {
+			word := text[start:lastWordEnd]
+			tokens = append(tokens, Token{
+				Text:       word,
+				Normalized: Normalize(word),
+				Start:      start,
+				End:        lastWordEnd,
+			})
+		}
+		start, lastWordEnd = -1, -1
+	}
+
+	for i, r := range text {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.Is(unicode.Mn, r):
+			if start < 0 {
+				start = i
+			}
+			lastWordEnd = i + len(string(r))
+		case start >= 0 && (isLeetSymbol(r) || unicode.Is(unicode.Cf, r)):
+			// Kept only if the word carries on after it: flush cuts the
+			// word at lastWordEnd, dropping trailing symbols
+		default:
+			flush()
+		}
+	}
+	flush()
+	return tokens
+}
+
+// Normalize reduces a word to the form filters compare against: lowercase,
+// with accents, leetspeak and invisible characters undone.
+func Normalize(word string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(word) {
+		if unicode.Is(unicode.Mn, r) || unicode.Is(unicode.Cf, r) {
+			continue
+		}
+		if base, ok := accents[r]; ok {
+			r = base
+		}
+		if letter, ok := leetspeak[r]; ok {
+			r = letter
+		}
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// Collapse reduces each run of a repeated letter in word to one, so that
+// "fooorrnaaax" becomes "fornax".
+func Collapse(word string) string {
+	var b strings.Builder
+	var last rune
+	for _, r := range word {
+		if r == last {
+			continue
+		}
+		b.WriteRune(r)
+		last = r
+	}
+	return b.String()
+}
+
+func isLeetSymbol(r rune) bool {
+	_, ok := leetspeak[r]
+	return ok && !unicode.IsDigit(r)
+}
@@ -0,0 +1,70 @@
+// Package pagination implements keyset pagination for lists ordered newest
+// first by a timestamp, with an ID to break ties.
+package pagination
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// DefaultLimit is the page size used when none is asked for.
+	DefaultLimit = 20
+	// MaxLimit is the largest page size that can be asked for.
+	MaxLimit = 100
+)
+
+var errInvalidCursor = errors.New("invalid cursor")
+
+// Cursor is the position of the last item on a page. The next page starts
+// with the first item ordered after it.
+type Cursor struct {
+	Time time.Time
+	ID   uuid.UUID
+}
+
+// String encodes the cursor as an opaque URL-safe token.
+func (c Cursor) String() string {
+	raw := strconv.FormatInt(c.Time.UnixNano(), 10) + "." + c.ID.String()
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// ParseCursor decodes a token made by Cursor.String.
+func ParseCursor(token string) (Cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return Cursor{}, errInvalidCursor
+	}
+	nanos, id, ok := strings.Cut(string(raw), ".")
+	if !ok {
+		return Cursor{}, errInvalidCursor
+	}
+	n, err := strconv.ParseInt(nanos, 10, 64)
+	if err != nil {
+		return Cursor{}, errInvalidCursor
+	}
+	parsedID, err := uuid.Parse(id)
+	if err != nil {
+		return Cursor{}, errInvalidCursor
+	}
+	return Cursor{Time: time.Unix(0, n).UTC(), ID: parsedID}, nil
+}
+
+// ParseLimit parses a requested page size. An empty string means
+// DefaultLimit.
+func ParseLimit(s string) (int, error) {
+	if s == "" {
+		return DefaultLimit, nil
+	}
+	limit, err := strconv.Atoi(s)
+	if err != nil || limit < 1 || limit > MaxLimit {
+		return 0, fmt.Errorf("limit must be a number from 1 to %d", MaxLimit)
+	}
+	return limit, nil
+}
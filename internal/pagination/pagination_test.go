@@ -0,0 +1,57 @@
+package pagination
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	cursor := Cursor{
+		Time: time.Date(2025, 4, 9, 15, 56, 40, 92149000, time.UTC),
+		ID:   uuid.New(),
+	}
+
+	parsed, err := ParseCursor(cursor.String())
+	if err != nil {
+		t.Fatalf("ParseCursor returned error: %v", err)
+	}
+	if !parsed.Time.Equal(cursor.Time) || parsed.ID != cursor.ID {
+		t.Errorf("ParseCursor = %+v, want %+v", parsed, cursor)
+	}
+}
+
+func TestParseCursorInvalid(t *testing.T) {
+	for _, token := range []string{"", "not base64!", "MTIzNA", "eC5ub3QtYS11dWlk"} {
+		if _, err := ParseCursor(token); err == nil {
+			t.Errorf("ParseCursor(%q) returned no error", token)
+		}
+	}
+}
+
+func TestParseLimit(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    int
+		wantErr bool
+	}{
+		{input: "", want: DefaultLimit},
+		{input: "5", want: 5},
+		{input: "100", want: MaxLimit},
+		{input: "0", wantErr: true},
+		{input: "101", wantErr: true},
+		{input: "ten", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseLimit(tt.input)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseLimit(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseLimit(%q) = %d, want %d", tt.input, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+// Limiter counts events per key over a sliding window. Limits are passed to
+// Allow rather than fixed up front so that keys can have different limits,
+// for example by subscription tier. It is safe for concurrent use.
+type Limiter struct {
+	window time.Duration
+	now    func() time.Time
+
+	mu        sync.Mutex
+	events    map[string][]time.Time
+	lastSweep time.Time
+}
+
+// New returns a Limiter that counts events over window.
+func New(window time.Duration) *Limiter {
+	return &Limiter{
+		window: window,
+		now:    time.Now,
+		events: map[string][]time.Time{},
+	}
+}
+
+// Allow records an event for key and reports whether it is within limit
+// events per window. Rejected events aren't recorded, so a client that keeps
+// retrying is let through again as soon as its oldest event expires.
+func (l *Limiter) Allow(key string, limit int) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	cutoff := now.Add(-l.window)
+	l.sweep(now, cutoff)
+
+	events := expire(l.events[key], cutoff)
+	if len(events) >= limit {
+		l.events[key] = events
+		return false
+	}
+	l.events[key] = append(events, now)
+	return true
+}
+
+// Refund takes back the most recent event allowed for key, for when the
+// action it stood for didn't happen after all.
+func (l *Limiter) Refund(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if events := l.events[key]; len(events) > 0 {
+		l.events[key] = events[:len(events)-1]
+	}
+}
+
+// sweep drops keys with no recent events, at most once per window, so keys
+// that stop sending don't stay in memory forever.
+func (l *Limiter) sweep(now, cutoff time.Time) {
+	if now.Sub(l.lastSweep) < l.window {
+		return
+	}
+	l.lastSweep = now
+	for key, events := range l.events {
+		if events = expire(events, cutoff); len(events) == 0 {
+			delete(l.events, key)
+		} else {
+			l.events[key] = events
+		}
+	}
+}
+
+// expire returns events without those at or before cutoff. Events are kept
+// in the order they happened, so the expired ones are always at the front.
+func expire(events []time.Time, cutoff time.Time) []time.Time {
+	i := 0
+	for i < len(events) && !events[i].After(cutoff) {
+		i++
+	}
+	return events[i:]
+}
@@ -0,0 +1,71 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAllow(t *testing.T) {
+	now := time.Date(2025, 4, 10, 9, 0, 0, 0, time.UTC)
+	l := New(time.Hour)
+	l.now = func() time.Time { return now }
+
+	for i := 0; i < 3; i++ {
+		if !l.Allow("alice", 3) {
+			t.Fatalf("event %d was rejected", i+1)
+		}
+		now = now.Add(time.Minute)
+	}
+	if l.Allow("alice", 3) {
+		t.Error("fourth event in the window was allowed")
+	}
+	if !l.Allow("bob", 3) {
+		t.Error("a different key was rejected")
+	}
+	if !l.Allow("alice", 10) {
+		t.Error("event was rejected under a higher limit")
+	}
+
+	now = now.Add(time.Hour)
+	if !l.Allow("alice", 3) {
+		t.Error("event was rejected after the window passed")
+	}
+}
+
+func TestRefund(t *testing.T) {
+	now := time.Date(2025, 4, 10, 9, 0, 0, 0, time.UTC)
+	l := New(time.Hour)
+	l.now = func() time.Time { return now }
+
+	l.Allow("alice", 2)
+	l.Allow("alice", 2)
+	l.Refund("alice")
+	if !l.Allow("alice", 2) {
+		t.Error("refunded event still counted")
+	}
+	if l.Allow("alice", 2) {
+		t.Error("event over the limit was allowed after a refund")
+	}
+
+	l.Refund("bob")
+	if _, ok := l.events["bob"]; ok {
+		t.Error("refunding an unknown key added it")
+	}
+}
+
+func TestSweepDropsIdleKeys(t *testing.T) {
+	now := time.Date(2025, 4, 10, 9, 0, 0, 0, time.UTC)
+	l := New(time.Minute)
+	l.now = func() time.Time { return now }
+
+	l.Allow("alice", 1)
+	now = now.Add(2 * time.Minute)
+	l.Allow("bob", 1)
+
+	if _, ok := l.events["alice"]; ok {
+		t.Error("idle key was not swept")
+	}
+	if _, ok := l.events["bob"]; !ok {
+		t.Error("active key was swept")
+	}
+}
@@ -0,0 +1,56 @@
+package spam
+
+import (
+	"hash/fnv"
+	"math/bits"
+	"strings"
+	"unicode"
+)
+
+// SimHash returns a 64-bit fingerprint of text in which similar texts differ
+// in few bits. Features are pairs of adjacent words, ignoring case and
+// punctuation, so reordering a few words or changing one moves the hash only
+// slightly. Text with no words hashes to 0.
+func SimHash(text string) uint64 {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	if len(words) == 0 {
+		return 0
+	}
+
+	features := words
+	if len(words) > 1 {
+		features = make([]string, 0, len(words)-1)
+		for i := 0; i+1 < len(words); i++ {
+			features = append(features, words[i]+" "+words[i+1])
+		}
+	}
+
+	var weights [64]int
+	for _, feature := range features {
+		h := fnv.New64a()
+		h.Write([]byte(feature))
+		sum := h.Sum64()
+		for bit := 0; bit < 64; bit++ {
+			if sum&(1<<bit) != 0 {
+				weights[bit]++
+			} else {
+				weights[bit]--
+			}
+		}
+	}
+
+	var hash uint64
+	for bit, weight := range weights {
+		if weight > 0 {
+			hash |= 1 << bit
+		}
+	}
+	return hash
+}
+
+// Distance is the number of bits in which two SimHashes differ.
+func Distance(a, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
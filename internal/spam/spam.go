@@ -0,0 +1,186 @@
+package spam
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/mjh1207/chirpy/internal/entities"
+)
+
+// Decision is what happens to a chirp after it has been scored.
+type Decision string
+
+const (
+	// Accept posts the chirp as normal.
+	Accept Decision = "accept"
+	// RateLimit refuses the chirp and asks the author to try again later.
+	RateLimit Decision = "rate_limit"
+	// Moderate posts the chirp hidden until a moderator reviews it.
+	Moderate Decision = "moderate"
+)
+
+// Decisions lists every decision, in increasing order of severity.
+var Decisions = []Decision{Accept, RateLimit, Moderate}
+
+// Config holds the weights and thresholds used to score chirps. A chirp's
+// score is the sum of the weights of the signals it trips.
+type Config struct {
+	// VelocityWindowMinutes is how far back posting velocity is measured.
+	VelocityWindowMinutes int `json:"velocity_window_minutes"`
+	// VelocityAllowance is how many chirps can be posted in the window
+	// before velocity adds to the score.
+	VelocityAllowance int `json:"velocity_allowance"`
+	// VelocityWeight is added for each chirp over the allowance.
+	VelocityWeight float64 `json:"velocity_weight"`
+
+	// DuplicateLookback is how many of the author's recent chirps a new
+	// chirp is compared against.
+	DuplicateLookback int `json:"duplicate_lookback"`
+	// DuplicateDistance is the largest SimHash distance at which two bodies
+	// count as near-duplicates.
+	DuplicateDistance int `json:"duplicate_distance"`
+	// DuplicateWeight is added for each near-duplicate found.
+	DuplicateWeight float64 `json:"duplicate_weight"`
+
+	// LinkDensity is the share of words that can be links before
+	// LinkWeight is added.
+	LinkDensity float64 `json:"link_density"`
+	LinkWeight  float64 `json:"link_weight"`
+
+	// NewAccountHours is how long an account counts as new, during which
+	// NewAccountWeight is added to every chirp.
+	NewAccountHours  int     `json:"new_account_hours"`
+	NewAccountWeight float64 `json:"new_account_weight"`
+
+	// RateLimitScore and ModerateScore are the scores at which a chirp is
+	// rate limited or sent to moderation.
+	RateLimitScore float64 `json:"rate_limit_score"`
+	ModerateScore  float64 `json:"moderate_score"`
+}
+
+// Default returns the built-in scoring configuration.
+func Default() Config {
+	return Config{
+		VelocityWindowMinutes: 10,
+		VelocityAllowance:     5,
+		VelocityWeight:        1,
+		DuplicateLookback:     20,
+		DuplicateDistance:     3,
+		DuplicateWeight:       2,
+		LinkDensity:           0.5,
+		LinkWeight:            2,
+		NewAccountHours:       24,
+		NewAccountWeight:      1.5,
+		RateLimitScore:        4,
+		ModerateScore:         8,
+	}
+}
+
+// Load reads a JSON configuration from r. Fields that aren't given keep
+// their default values.
+func Load(r io.Reader) (Config, error) {
+	config := Default()
+	decoder := json.NewDecoder(r)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&config); err != nil {
+		return Config{}, err
+	}
+	if err := config.validate(); err != nil {
+		return Config{}, err
+	}
+	return config, nil
+}
+
+// VelocityWindow is VelocityWindowMinutes as a duration.
+func (c Config) VelocityWindow() time.Duration {
+	return time.Duration(c.VelocityWindowMinutes) * time.Minute
+}
+
+func (c Config) validate() error {
+	if c.VelocityWindowMinutes <= 0 {
+		return errors.New("velocity_window_minutes must be positive")
+	}
+	if c.DuplicateLookback < 0 || c.DuplicateDistance < 0 || c.NewAccountHours < 0 {
+		return errors.New("duplicate_lookback, duplicate_distance and new_account_hours can't be negative")
+	}
+	if c.RateLimitScore <= 0 || c.ModerateScore < c.RateLimitScore {
+		return errors.New("rate_limit_score must be positive and no more than moderate_score")
+	}
+	return nil
+}
+
+// Signals is what is known about a chirp and its author when it is scored.
+type Signals struct {
+	Body string
+	// RecentChirps is how many chirps the author posted in the velocity
+	// window.
+	RecentChirps int
+	// RecentBodies are the author's most recent chirps, newest first.
+	RecentBodies []string
+	AccountAge   time.Duration
+}
+
+// Verdict is the outcome of scoring a chirp. Reasons describe each signal
+// that added to the score.
+type Verdict struct {
+	Score    float64
+	Decision Decision
+	Reasons  []string
+}
+
+// Evaluate scores a chirp and decides what to do with it.
+func (c Config) Evaluate(s Signals) Verdict {
+	var v Verdict
+
+	if over := s.RecentChirps - c.VelocityAllowance; over > 0 {
+		v.add(float64(over)*c.VelocityWeight, fmt.Sprintf("%d chirps in %d minutes", s.RecentChirps, c.VelocityWindowMinutes))
+	}
+
+	if hash := SimHash(s.Body); hash != 0 {
+		duplicates := 0
+		for i, body := range s.RecentBodies {
+			if i >= c.DuplicateLookback {
+				break
+			}
+			if Distance(hash, SimHash(body)) <= c.DuplicateDistance {
+				duplicates++
+			}
+		}
+		if duplicates > 0 {
+			v.add(float64(duplicates)*c.DuplicateWeight, fmt.Sprintf("%d near-duplicate recent chirps", duplicates))
+		}
+	}
+
+	if words := len(strings.Fields(s.Body)); words > 0 {
+		links := len(entities.ParseURLs(s.Body))
+		if float64(links)/float64(words) > c.LinkDensity {
+			v.add(c.LinkWeight, fmt.Sprintf("%d links in %d words", links, words))
+		}
+	}
+
+	if s.AccountAge < time.Duration(c.NewAccountHours)*time.Hour {
+		v.add(c.NewAccountWeight, "new account")
+	}
+
+	switch {
+	case v.Score >= c.ModerateScore:
+		v.Decision = Moderate
+	case v.Score >= c.RateLimitScore:
+		v.Decision = RateLimit
+	default:
+		v.Decision = Accept
+	}
+	return v
+}
+
+func (v *Verdict) add(score float64, reason string) {
+	if score <= 0 {
+		return
+	}
+	v.Score += score
+	v.Reasons = append(v.Reasons, reason)
+}
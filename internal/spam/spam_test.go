@@ -0,0 +1,99 @@
+package spam
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSimHash(t *testing.T) {
+	base := SimHash("Check out my amazing new product at the store today, it is really great")
+	similar := SimHash("check out my AMAZING new product at the store today!! it is really great")
+	different := SimHash("The weather in Lisbon was lovely and we walked along the river all afternoon")
+
+	if d := Distance(base, similar); d > 3 {
+		t.Errorf("near-duplicates are %d bits apart, want at most 3", d)
+	}
+	if d := Distance(base, different); d <= 3 {
+		t.Errorf("unrelated texts are only %d bits apart", d)
+	}
+	if SimHash("!!! ...") != 0 {
+		t.Error("text without words should hash to 0")
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	config := Default()
+	established := 30 * 24 * time.Hour
+	spamBody := "Buy cheap followers now at the best price on the internet"
+
+	tests := []struct {
+		name    string
+		signals Signals
+		want    Decision
+	}{
+		{
+			name:    "normal chirp",
+			signals: Signals{Body: "Lovely day for a walk", RecentChirps: 1, AccountAge: established},
+			want:    Accept,
+		},
+		{
+			name:    "new account alone",
+			signals: Signals{Body: "Hello everyone", AccountAge: time.Hour},
+			want:    Accept,
+		},
+		{
+			name: "repeated body",
+			signals: Signals{
+				Body:         spamBody,
+				RecentBodies: []string{spamBody, spamBody},
+				AccountAge:   established,
+			},
+			want: RateLimit,
+		},
+		{
+			name: "flood from a new account",
+			signals: Signals{
+				Body:         spamBody,
+				RecentChirps: 12,
+				RecentBodies: []string{spamBody, spamBody},
+				AccountAge:   time.Hour,
+			},
+			want: Moderate,
+		},
+		{
+			name:    "link dump",
+			signals: Signals{Body: "https://a.example https://b.example look", AccountAge: time.Hour, RecentChirps: 11},
+			want:    Moderate,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			verdict := config.Evaluate(tt.signals)
+			if verdict.Decision != tt.want {
+				t.Errorf("Decision = %q (score %.1f, reasons %q), want %q", verdict.Decision, verdict.Score, verdict.Reasons, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	config, err := Load(strings.NewReader(`{"moderate_score": 12}`))
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	if config.ModerateScore != 12 || config.RateLimitScore != Default().RateLimitScore {
+		t.Errorf("Load didn't merge with defaults: %+v", config)
+	}
+
+	for _, input := range []string{
+		`{"moderate_score": 1}`,
+		`{"velocity_window_minutes": 0}`,
+		`{"moderate_scor": 12}`,
+	} {
+		if _, err := Load(strings.NewReader(input)); err == nil {
+			t.Errorf("Load(%s) returned no error", input)
+		}
+	}
+}
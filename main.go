@@ -1,23 +1,43 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"log"
 	"net/http"
 	"os"
 	"sync/atomic"
+	"time"
 
 	"github.com/joho/godotenv"
 	_ "github.com/lib/pq"
+	"github.com/mjh1207/chirpy/internal/analytics"
 	"github.com/mjh1207/chirpy/internal/database"
+	"github.com/mjh1207/chirpy/internal/encryption"
+	"github.com/mjh1207/chirpy/internal/entitlements"
+	"github.com/mjh1207/chirpy/internal/media"
+	"github.com/mjh1207/chirpy/internal/moderation"
+	"github.com/mjh1207/chirpy/internal/ratelimit"
+	"github.com/mjh1207/chirpy/internal/spam"
 )
 
 type apiConfig struct {
 	fileserverHits atomic.Int32
 	db *database.Queries
+	conn *sql.DB
 	platform string
 	jwtSecret string
 	polkaKey string
+	adminKey string
+	trending trendingTags
+	mediaStore media.BlobStore
+	entitlements entitlements.Config
+	chirpLimiter *ratelimit.Limiter
+	moderationPipeline atomic.Pointer[moderation.Pipeline]
+	spam spam.Config
+	spamDecisions spamCounters
+	chirpStats *analytics.Buffer
+	dmBox *encryption.Box
 }
 
 func main() {
@@ -37,6 +57,50 @@ func main() {
 		log.Fatal("POLKA_KEY must be set")
 	}
 
+	dmKey, err := encryption.ParseKey(os.Getenv("DM_KEY"))
+	if err != nil {
+		log.Fatalf("DM_KEY must be set to a base64 encoded %d byte key: %v", encryption.KeySize, err)
+	}
+	dmBox, err := encryption.New(dmKey)
+	if err != nil {
+		log.Fatalf("unable to create message cipher: %v", err)
+	}
+
+	mediaDir := os.Getenv("MEDIA_DIR")
+	if mediaDir == "" {
+		mediaDir = "media"
+	}
+	mediaStore, err := media.NewLocalStore(mediaDir)
+	if err != nil {
+		log.Fatalf("unable to create media directory: %v", err)
+	}
+
+	tiers := entitlements.Default()
+	if path := os.Getenv("ENTITLEMENTS_FILE"); path != "" {
+		f, err := os.Open(path)
+		if err != nil {
+			log.Fatalf("unable to open entitlements file: %v", err)
+		}
+		tiers, err = entitlements.Load(f)
+		f.Close()
+		if err != nil {
+			log.Fatalf("unable to load entitlements: %v", err)
+		}
+	}
+
+	spamConfig := spam.Default()
+	if path := os.Getenv("SPAM_CONFIG_FILE"); path != "" {
+		f, err := os.Open(path)
+		if err != nil {
+			log.Fatalf("unable to open spam config file: %v", err)
+		}
+		spamConfig, err = spam.Load(f)
+		f.Close()
+		if err != nil {
+			log.Fatalf("unable to load spam config: %v", err)
+		}
+	}
+
 	dbConn, err := sql.Open("postgres", dbURL)
 	if err != nil {
 		log.Fatalf("unable to open sql connection: %v", err)
@@ -46,9 +110,21 @@ func main() {
 	apiCfg := apiConfig {
 		fileserverHits: atomic.Int32{},
 		db: database.New(dbConn),
+		conn: dbConn,
 		platform: os.Getenv("PLATFORM"),
 		jwtSecret: secret,
 		polkaKey: pKey,
+		adminKey: os.Getenv("ADMIN_KEY"),
+		mediaStore: mediaStore,
+		entitlements: tiers,
+		chirpLimiter: ratelimit.New(time.Hour),
+		spam: spamConfig,
+		spamDecisions: newSpamCounters(),
+		chirpStats: analytics.NewBuffer(statsBufferKeys),
+		dmBox: dmBox,
+	}
+	if err := apiCfg.reloadModeration(context.Background()); err != nil {
+		log.Fatalf("unable to load moderation word lists: %v", err)
 	}
 
 
@@ -57,21 +133,124 @@ func main() {
 	mux.Handle("/app/", apiCfg.middlewareMetricsInc(handler))
 	mux.HandleFunc("GET /api/healthz", handlerReadiness)
 	mux.HandleFunc("GET /admin/metrics", apiCfg.handlerMetrics)
+	mux.HandleFunc("GET /admin/metrics/spam", apiCfg.handlerSpamMetrics)
 	mux.HandleFunc("POST /admin/reset", apiCfg.handlerReset)
 
 	mux.HandleFunc("POST /api/login", apiCfg.handlerLogin)
 	mux.HandleFunc("POST /api/users", apiCfg.handlerUsers)
 	mux.HandleFunc("PUT /api/users", apiCfg.handlerUpdateUser)
+	mux.HandleFunc("GET /api/users/me/mentions", apiCfg.handlerGetMentions)
+	mux.HandleFunc("GET /api/users/me/entitlements", apiCfg.handlerGetEntitlements)
+	mux.HandleFunc("GET /api/users/me/preferences", apiCfg.handlerGetPreferences)
+	mux.HandleFunc("GET /api/users/me/chirps/export", apiCfg.handlerExportChirps)
+	mux.HandleFunc("GET /api/users/me/analytics", apiCfg.handlerGetAnalytics)
+	mux.HandleFunc("GET /api/users/me/blocks", apiCfg.handlerGetBlocks)
+	mux.HandleFunc("GET /api/users/me/mutes", apiCfg.handlerGetMutes)
+	mux.HandleFunc("GET /api/users/me/follow-requests", apiCfg.handlerGetFollowRequests)
+	mux.HandleFunc("POST /api/users/me/follow-requests/{userID}/approve", apiCfg.handlerApproveFollowRequest)
+	mux.HandleFunc("DELETE /api/users/me/follow-requests/{userID}", apiCfg.handlerRejectFollowRequest)
+	mux.HandleFunc("GET /api/users/me/subscribed-lists", apiCfg.handlerGetSubscribedLists)
+	mux.HandleFunc("PUT /api/users/me/preferences", apiCfg.handlerUpdatePreferences)
+	mux.HandleFunc("PUT /api/users/me/privacy", apiCfg.handlerUpdatePrivacy)
 
 	mux.HandleFunc("POST /api/chirps", apiCfg.handlerPostChirps)
 	mux.HandleFunc("GET /api/chirps", apiCfg.handlerGetChirps)
+	mux.HandleFunc("GET /api/timeline/home", apiCfg.handlerGetHomeTimeline)
 	mux.HandleFunc("GET /api/chirps/{chirpID}", apiCfg.handlerGetChirp)
+	mux.HandleFunc("PUT /api/chirps/{chirpID}", apiCfg.handlerEditChirp)
 	mux.HandleFunc("DELETE /api/chirps/{chirpID}", apiCfg.handlerDeleteChirp)
+	mux.HandleFunc("POST /api/chirps/{chirpID}/like", apiCfg.handlerLikeChirp)
+	mux.HandleFunc("DELETE /api/chirps/{chirpID}/like", apiCfg.handlerUnlikeChirp)
+	mux.HandleFunc("GET /api/users/{userID}/likes", apiCfg.handlerGetUserLikes)
+	mux.HandleFunc("POST /api/users/{userID}/follow", apiCfg.handlerFollowUser)
+	mux.HandleFunc("DELETE /api/users/{userID}/follow", apiCfg.handlerUnfollowUser)
+	mux.HandleFunc("GET /api/users/{userID}/followers", apiCfg.handlerGetFollowers)
+	mux.HandleFunc("GET /api/users/{userID}/following", apiCfg.handlerGetFollowing)
+	mux.HandleFunc("POST /api/users/{userID}/block", apiCfg.handlerBlockUser)
+	mux.HandleFunc("DELETE /api/users/{userID}/block", apiCfg.handlerUnblockUser)
+	mux.HandleFunc("POST /api/users/{userID}/mute", apiCfg.handlerMuteUser)
+	mux.HandleFunc("DELETE /api/users/{userID}/mute", apiCfg.handlerUnmuteUser)
+	mux.HandleFunc("GET /api/users/{userID}/lists", apiCfg.handlerGetUserLists)
 	
+	mux.HandleFunc("POST /api/lists", apiCfg.handlerCreateList)
+	mux.HandleFunc("GET /api/lists/{listID}", apiCfg.handlerGetList)
+	mux.HandleFunc("PUT /api/lists/{listID}", apiCfg.handlerUpdateList)
+	mux.HandleFunc("DELETE /api/lists/{listID}", apiCfg.handlerDeleteList)
+	mux.HandleFunc("GET /api/lists/{listID}/timeline", apiCfg.handlerGetListTimeline)
+	mux.HandleFunc("GET /api/lists/{listID}/members", apiCfg.handlerGetListMembers)
+	mux.HandleFunc("POST /api/lists/{listID}/members/{userID}", apiCfg.handlerAddListMember)
+	mux.HandleFunc("DELETE /api/lists/{listID}/members/{userID}", apiCfg.handlerRemoveListMember)
+	mux.HandleFunc("POST /api/lists/{listID}/subscribe", apiCfg.handlerSubscribeList)
+	mux.HandleFunc("DELETE /api/lists/{listID}/subscribe", apiCfg.handlerUnsubscribeList)
+
+	mux.HandleFunc("POST /api/conversations", apiCfg.handlerCreateConversation)
+	mux.HandleFunc("GET /api/conversations", apiCfg.handlerGetConversations)
+	mux.HandleFunc("GET /api/conversations/{conversationID}", apiCfg.handlerGetConversation)
+	mux.HandleFunc("GET /api/conversations/{conversationID}/messages", apiCfg.handlerGetMessages)
+	mux.HandleFunc("POST /api/conversations/{conversationID}/messages", apiCfg.handlerSendMessage)
+	mux.HandleFunc("DELETE /api/conversations/{conversationID}/messages/{messageID}", apiCfg.handlerDeleteMessage)
+	mux.HandleFunc("POST /api/conversations/{conversationID}/read", apiCfg.handlerMarkConversationRead)
+	mux.HandleFunc("POST /api/conversations/{conversationID}/mute", apiCfg.handlerMuteConversation)
+	mux.HandleFunc("DELETE /api/conversations/{conversationID}/mute", apiCfg.handlerUnmuteConversation)
+
+	mux.HandleFunc("POST /api/chirps/{chirpID}/vote", apiCfg.handlerVotePoll)
+	mux.HandleFunc("POST /api/chirps/{chirpID}/restore", apiCfg.handlerRestoreChirp)
+	mux.HandleFunc("POST /api/chirps/{chirpID}/pin", apiCfg.handlerPinChirp)
+	mux.HandleFunc("DELETE /api/chirps/{chirpID}/pin", apiCfg.handlerUnpinChirp)
+	mux.HandleFunc("POST /api/chirps/{chirpID}/bookmark", apiCfg.handlerBookmarkChirp)
+	mux.HandleFunc("DELETE /api/chirps/{chirpID}/bookmark", apiCfg.handlerDeleteBookmark)
+	mux.HandleFunc("GET /api/bookmarks", apiCfg.handlerGetBookmarks)
+	mux.HandleFunc("GET /api/bookmarks/folders", apiCfg.handlerGetBookmarkFolders)
+	mux.HandleFunc("POST /api/bookmarks/folders", apiCfg.handlerCreateBookmarkFolder)
+	mux.HandleFunc("PUT /api/bookmarks/folders/{folderID}", apiCfg.handlerRenameBookmarkFolder)
+	mux.HandleFunc("DELETE /api/bookmarks/folders/{folderID}", apiCfg.handlerDeleteBookmarkFolder)
+	mux.HandleFunc("GET /api/deleted-chirps", apiCfg.handlerGetDeletedChirps)
+
+	mux.HandleFunc("POST /api/drafts", apiCfg.handlerCreateDraft)
+	mux.HandleFunc("GET /api/drafts", apiCfg.handlerGetDrafts)
+	mux.HandleFunc("GET /api/drafts/{draftID}", apiCfg.handlerGetDraft)
+	mux.HandleFunc("PUT /api/drafts/{draftID}", apiCfg.handlerUpdateDraft)
+	mux.HandleFunc("DELETE /api/drafts/{draftID}", apiCfg.handlerDeleteDraft)
+	mux.HandleFunc("POST /api/drafts/{draftID}/publish", apiCfg.handlerPublishDraft)
+
+	mux.HandleFunc("GET /api/scheduled-chirps", apiCfg.handlerGetScheduledChirps)
+	mux.HandleFunc("PUT /api/scheduled-chirps/{chirpID}", apiCfg.handlerRescheduleChirp)
+	mux.HandleFunc("DELETE /api/scheduled-chirps/{chirpID}", apiCfg.handlerCancelScheduledChirp)
+
+	mux.HandleFunc("POST /api/media", apiCfg.handlerUploadMedia)
+	mux.HandleFunc("GET /api/media/{mediaID}", apiCfg.handlerGetMedia)
+	mux.HandleFunc("GET /api/media/{mediaID}/thumbnail", apiCfg.handlerGetMediaThumbnail)
+
+	mux.HandleFunc("GET /api/moderation/words", apiCfg.handlerGetModerationWords)
+	mux.HandleFunc("POST /api/moderation/words", apiCfg.handlerCreateModerationWord)
+	mux.HandleFunc("DELETE /api/moderation/words/{wordID}", apiCfg.handlerDeleteModerationWord)
+	mux.HandleFunc("GET /api/moderation/flags", apiCfg.handlerGetChirpFlags)
+	mux.HandleFunc("POST /api/moderation/chirps/{chirpID}/unhide", apiCfg.handlerUnhideChirp)
+	mux.HandleFunc("PUT /api/moderation/chirps/{chirpID}/content-warning", apiCfg.handlerForceContentWarning)
+	mux.HandleFunc("DELETE /api/moderation/chirps/{chirpID}/content-warning", apiCfg.handlerLiftContentWarning)
+	mux.HandleFunc("GET /api/moderation/reports", apiCfg.handlerGetReportQueue)
+	mux.HandleFunc("GET /api/moderation/reports/{reportID}", apiCfg.handlerGetReport)
+	mux.HandleFunc("POST /api/moderation/reports/{reportID}/assign", apiCfg.handlerAssignReport)
+	mux.HandleFunc("DELETE /api/moderation/reports/{reportID}/assign", apiCfg.handlerUnassignReport)
+	mux.HandleFunc("POST /api/moderation/reports/{reportID}/resolve", apiCfg.handlerResolveReport)
+
+	mux.HandleFunc("POST /api/reports", apiCfg.handlerCreateReport)
+	mux.HandleFunc("GET /api/reports", apiCfg.handlerGetMyReports)
+
+	mux.HandleFunc("GET /api/hashtags/{tag}/chirps", apiCfg.handlerGetHashtagChirps)
+	mux.HandleFunc("GET /api/trending", apiCfg.handlerGetTrending)
+
 	mux.HandleFunc("POST /api/refresh", apiCfg.handlerRefresh)
 	mux.HandleFunc("POST /api/revoke", apiCfg.handlerRevoke)
 
 	mux.HandleFunc("POST /api/polka/webhooks", apiCfg.handlerWebhooks)
+	mux.HandleFunc("POST /api/admin/import", apiCfg.handlerImportChirps)
+
+	go apiCfg.runTrending(context.Background())
+	go apiCfg.runPublisher(context.Background())
+	go apiCfg.runPurger(context.Background())
+	go apiCfg.runModerationReload(context.Background())
+	go apiCfg.runStatsFlush(context.Background())
 
 	server := &http.Server {
 		Handler: mux,
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mjh1207/chirpy/internal/analytics"
+	"github.com/mjh1207/chirpy/internal/auth"
+	"github.com/mjh1207/chirpy/internal/database"
+	"github.com/mjh1207/chirpy/internal/encryption"
+	"github.com/mjh1207/chirpy/internal/entitlements"
+	"github.com/mjh1207/chirpy/internal/ratelimit"
+	"github.com/mjh1207/chirpy/internal/spam"
+)
+
+// newTestConfig returns an apiConfig backed by the Postgres database in
+// TEST_DB_URL, with every migration applied to an empty public schema. The
+// database is wiped, so it must be one kept for tests. Tests are skipped
+// when TEST_DB_URL isn't set.
+func newTestConfig(t *testing.T) *apiConfig {
+	t.Helper()
+	dbURL := os.Getenv("TEST_DB_URL")
+	if dbURL == "" {
+		t.Skip("TEST_DB_URL not set")
+	}
+
+	conn, err := sql.Open("postgres", dbURL)
+	if err != nil {
+		t.Fatalf("Couldn't open database: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	migrateTestDB(t, conn)
+
+	dmBox, err := encryption.New(make([]byte, encryption.KeySize))
+	if err != nil {
+		t.Fatalf("Couldn't create message cipher: %v", err)
+	}
+	cfg := &apiConfig{
+		db:            database.New(conn),
+		conn:          conn,
+		platform:      "dev",
+		jwtSecret:     "test-secret",
+		entitlements:  entitlements.Default(),
+		chirpLimiter:  ratelimit.New(time.Hour),
+		spam:          spam.Default(),
+		spamDecisions: newSpamCounters(),
+		chirpStats:    analytics.NewBuffer(statsBufferKeys),
+		dmBox:         dmBox,
+	}
+	if err := cfg.reloadModeration(context.Background()); err != nil {
+		t.Fatalf("Couldn't load moderation word lists: %v", err)
+	}
+	return cfg
+}
+
+// migrateTestDB recreates the public schema and runs the Up section of
+// every migration in sql/schema, in order.
+func migrateTestDB(t *testing.T, conn *sql.DB) {
+	t.Helper()
+	if _, err := conn.Exec("DROP SCHEMA public CASCADE; CREATE SCHEMA public;"); err != nil {
+		t.Fatalf("Couldn't reset schema: %v", err)
+	}
+
+	files, err := filepath.Glob("sql/schema/*.sql")
+	if err != nil {
+		t.Fatalf("Couldn't list migrations: %v", err)
+	}
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatalf("Couldn't read %s: %v", file, err)
+		}
+		up, _, _ := strings.Cut(string(data), "-- +goose Down")
+		var statements []string
+		for _, line := range strings.Split(up, "\n") {
+			if !strings.HasPrefix(strings.TrimSpace(line), "-- +goose") {
+				statements = append(statements, line)
+			}
+		}
+		if _, err := conn.Exec(strings.Join(statements, "\n")); err != nil {
+			t.Fatalf("Couldn't apply %s: %v", file, err)
+		}
+	}
+}
+
+// createTestUser adds a user with the given handle and returns it with a
+// token for it.
+func createTestUser(t *testing.T, cfg *apiConfig, handle string) (database.User, string) {
+	t.Helper()
+	user, err := cfg.db.CreateUser(context.Background(), database.CreateUserParams{
+		Email:          handle + "@example.com",
+		HashedPassword: "unused",
+		Handle:         sql.NullString{String: handle, Valid: true},
+	})
+	if err != nil {
+		t.Fatalf("Couldn't create user %s: %v", handle, err)
+	}
+	token, err := auth.MakeJWT(user.ID, cfg.jwtSecret)
+	if err != nil {
+		t.Fatalf("Couldn't make token: %v", err)
+	}
+	return user, token
+}
+
+// doTestRequest serves one request to handler, routed by pattern so that
+// path values are set, and decodes a JSON response into out if it isn't
+// nil. body is sent as JSON unless it is already a string.
+func doTestRequest(t *testing.T, pattern string, handler http.HandlerFunc, method, target, token string, body any, out any) *httptest.ResponseRecorder {
+	t.Helper()
+	var reader *bytes.Reader
+	switch body := body.(type) {
+	case nil:
+		reader = bytes.NewReader(nil)
+	case string:
+		reader = bytes.NewReader([]byte(body))
+	default:
+		data, err := json.Marshal(body)
+		if err != nil {
+			t.Fatalf("Couldn't encode request body: %v", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req := httptest.NewRequest(method, target, reader)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc(pattern, handler)
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+
+	if out != nil && rec.Code < 300 {
+		if err := json.Unmarshal(rec.Body.Bytes(), out); err != nil {
+			t.Fatalf("Couldn't decode response %q: %v", rec.Body.String(), err)
+		}
+	}
+	return rec
+}
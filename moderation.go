@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mjh1207/chirpy/internal/database"
+	"github.com/mjh1207/chirpy/internal/moderation"
+)
+
+// moderationReloadInterval is how often the word lists are reloaded from the
+// database. Changes made through this server's admin endpoints take effect
+// straight away; the interval only matters for changes made elsewhere.
+const moderationReloadInterval = time.Minute
+
+// runModerationReload reloads the moderation pipeline every
+// moderationReloadInterval until ctx is cancelled.
+func (cfg *apiConfig) runModerationReload(ctx context.Context) {
+	ticker := time.NewTicker(moderationReloadInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if err := cfg.reloadModeration(ctx); err != nil {
+			log.Printf("Couldn't reload moderation word lists: %v", err)
+		}
+	}
+}
+
+// reloadModeration builds a new pipeline from the word lists in the database
+// and swaps it in. Requests already being moderated keep the old pipeline.
+func (cfg *apiConfig) reloadModeration(ctx context.Context) error {
+	words, err := cfg.db.GetModerationWords(ctx)
+	if err != nil {
+		return err
+	}
+
+	terms := map[moderation.Action][]moderation.Term{}
+	for _, word := range words {
+		action := moderation.Action(word.Action)
+		terms[action] = append(terms[action], moderation.Term{
+			Word:   word.Word,
+			Reason: word.Reason,
+		})
+	}
+
+	pipeline := moderation.NewPipeline(
+		moderation.NewWordList("reject_words", moderation.Reject, terms[moderation.Reject]),
+		moderation.NewWordList("flag_words", moderation.Flag, terms[moderation.Flag]),
+		moderation.NewWordList("mask_words", moderation.Mask, terms[moderation.Mask]),
+	)
+	cfg.moderationPipeline.Store(pipeline)
+	return nil
+}
+
+// moderate runs text through the current pipeline. Rejected text is
+// returned as a *chirpError carrying the filter's reason.
+func (cfg *apiConfig) moderate(text string) (moderation.Result, error) {
+	result := cfg.moderationPipeline.Load().Moderate(text)
+	if result.Rejected() {
+		reason := result.Rejection.Reason
+		if reason == "" {
+			reason = "it contains a blocked word"
+		}
+		return result, &chirpError{http.StatusBadRequest, fmt.Sprintf("Chirp rejected: %s", reason)}
+	}
+	return result, nil
+}
+
+// saveFlags records flagged matches against a chirp for moderators to review.
+func saveFlags(ctx context.Context, qtx *database.Queries, chirpID uuid.UUID, flags []moderation.Match) error {
+	for _, flag := range flags {
+		reason := flag.Reason
+		if reason == "" {
+			reason = fmt.Sprintf("Matched %q", flag.Term)
+		}
+		err := qtx.CreateChirpFlag(ctx, database.CreateChirpFlagParams{
+			ChirpID: chirpID,
+			Filter:  flag.Filter,
+			Reason:  reason,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/mjh1207/chirpy/internal/auth"
+	"github.com/mjh1207/chirpy/internal/entities"
+	"github.com/mjh1207/chirpy/internal/pagination"
+)
+
+// authenticate returns the ID of the user whose access token is attached to the request.
+func (cfg *apiConfig) authenticate(req *http.Request) (uuid.UUID, error) {
+	token, err := auth.GetBearerToken(req.Header)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	return auth.ValidateJWT(token, cfg.jwtSecret)
+}
+
+// viewerID is like authenticate for endpoints that also serve anonymous
+// readers. It returns uuid.Nil when no Authorization header is present, but
+// still rejects a token that is present and invalid.
+func (cfg *apiConfig) viewerID(req *http.Request) (uuid.UUID, error) {
+	if req.Header.Get("Authorization") == "" {
+		return uuid.Nil, nil
+	}
+	return cfg.authenticate(req)
+}
+
+// parseHandle validates a handle from a request body. An empty handle is
+// returned as NULL so that updates leave the stored handle unchanged.
+func parseHandle(handle string) (sql.NullString, error) {
+	if handle == "" {
+		return sql.NullString{}, nil
+	}
+	handle = entities.NormalizeHandle(handle)
+	if !entities.ValidHandle(handle) {
+		return sql.NullString{}, fmt.Errorf("handle must be 1-%d letters, digits or underscores", entities.MaxHandleLength)
+	}
+	return sql.NullString{String: handle, Valid: true}, nil
+}
+
+// isUniqueViolation reports whether err was caused by a UNIQUE constraint.
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
+
+// parsePage reads the cursor and limit query parameters of a paginated list.
+// The cursor is nil on the first page.
+func parsePage(req *http.Request) (*pagination.Cursor, int, error) {
+	limit, err := pagination.ParseLimit(req.URL.Query().Get("limit"))
+	if err != nil {
+		return nil, 0, err
+	}
+	token := req.URL.Query().Get("cursor")
+	if token == "" {
+		return nil, limit, nil
+	}
+	cursor, err := pagination.ParseCursor(token)
+	if err != nil {
+		return nil, 0, err
+	}
+	return &cursor, limit, nil
+}
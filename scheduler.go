@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mjh1207/chirpy/internal/analytics"
+)
+
+const publishInterval = 30 * time.Second
+
+// runPublisher publishes scheduled chirps once their publish_at time has
+// passed. Schedules live in the chirps table, so anything that came due while
+// the server was down is published on the first pass after a restart.
+func (cfg *apiConfig) runPublisher(ctx context.Context) {
+	ticker := time.NewTicker(publishInterval)
+	defer ticker.Stop()
+	for {
+		if err := cfg.publishDueChirps(ctx); err != nil {
+			log.Printf("Couldn't publish scheduled chirps: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (cfg *apiConfig) publishDueChirps(ctx context.Context) error {
+	tx, err := cfg.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	published, err := qtx.PublishDueChirps(ctx)
+	if err != nil {
+		return err
+	}
+	if len(published) == 0 {
+		return nil
+	}
+
+	chirpIDs := make([]uuid.UUID, 0, len(published))
+	for _, chirp := range published {
+		chirpIDs = append(chirpIDs, chirp.ID)
+	}
+	// Count the chirps' hashtags towards trending from when they went live
+	if err := qtx.TouchChirpHashtags(ctx, chirpIDs); err != nil {
+		return err
+	}
+	if err := fanOutChirps(ctx, qtx, chirpIDs); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	for _, chirp := range published {
+		if chirp.InReplyToID.Valid {
+			cfg.chirpStats.Record(chirp.InReplyToID.UUID, analytics.Reply)
+		}
+	}
+	log.Printf("Published %d scheduled chirps", len(published))
+	return nil
+}
+
+const purgeInterval = time.Hour
+
+// runPurger permanently deletes chirps that have been in the trash for longer
+// than chirpTrashWindow, along with mutes that have expired.
+func (cfg *apiConfig) runPurger(ctx context.Context) {
+	ticker := time.NewTicker(purgeInterval)
+	defer ticker.Stop()
+	for {
+		if err := cfg.purgeDeletedChirps(ctx); err != nil {
+			log.Printf("Couldn't purge deleted chirps: %v", err)
+		}
+		// Expired mutes are already ignored, this only keeps the table small
+		if _, err := cfg.db.DeleteExpiredMutes(ctx); err != nil {
+			log.Printf("Couldn't delete expired mutes: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (cfg *apiConfig) purgeDeletedChirps(ctx context.Context) error {
+	chirpIDs, err := cfg.db.GetChirpsToPurge(ctx, time.Now().UTC().Add(-chirpTrashWindow))
+	if err != nil {
+		return err
+	}
+	for _, chirpID := range chirpIDs {
+		if err := cfg.purgeChirp(ctx, chirpID); err != nil {
+			return err
+		}
+	}
+	if len(chirpIDs) > 0 {
+		log.Printf("Purged %d deleted chirps", len(chirpIDs))
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mjh1207/chirpy/internal/database"
+	"github.com/mjh1207/chirpy/internal/spam"
+)
+
+// spamDuplicateWindow is how far back the author's chirps are searched for
+// near-duplicates.
+const spamDuplicateWindow = 24 * time.Hour
+
+// spamCounters counts spam decisions for the metrics endpoint. The map is
+// filled in once by newSpamCounters and only the counters change after that.
+type spamCounters map[spam.Decision]*atomic.Int64
+
+func newSpamCounters() spamCounters {
+	counters := spamCounters{}
+	for _, decision := range spam.Decisions {
+		counters[decision] = &atomic.Int64{}
+	}
+	return counters
+}
+
+// scoreChirp gathers the author's recent activity and scores body for spam.
+// chirpID is the chirp being edited, which is left out of the author's
+// activity, or uuid.Nil for a new chirp. Every verdict is counted in
+// cfg.spamDecisions.
+func (cfg *apiConfig) scoreChirp(ctx context.Context, qtx *database.Queries, userID, chirpID uuid.UUID, body string) (spam.Verdict, error) {
+	user, err := qtx.GetUser(ctx, userID)
+	if err != nil {
+		return spam.Verdict{}, err
+	}
+
+	now := time.Now().UTC()
+	recent, err := qtx.CountRecentChirpsForUser(ctx, database.CountRecentChirpsForUserParams{
+		UserID:    userID,
+		CreatedAt: now.Add(-cfg.spam.VelocityWindow()),
+		ID:        chirpID,
+	})
+	if err != nil {
+		return spam.Verdict{}, err
+	}
+
+	bodies, err := qtx.GetRecentChirpBodiesForUser(ctx, database.GetRecentChirpBodiesForUserParams{
+		UserID:    userID,
+		CreatedAt: now.Add(-spamDuplicateWindow),
+		ID:        chirpID,
+		Limit:     int32(cfg.spam.DuplicateLookback),
+	})
+	if err != nil {
+		return spam.Verdict{}, err
+	}
+
+	verdict := cfg.spam.Evaluate(spam.Signals{
+		Body:         body,
+		RecentChirps: int(recent),
+		RecentBodies: bodies,
+		AccountAge:   now.Sub(user.CreatedAt),
+	})
+	cfg.spamDecisions[verdict.Decision].Add(1)
+	return verdict, nil
+}
+
+// spamFlagReason describes a verdict for the moderation flag queue.
+func spamFlagReason(verdict spam.Verdict) string {
+	return fmt.Sprintf("Spam score %.1f: %s", verdict.Score, strings.Join(verdict.Reasons, ", "))
+}
+
+// handlerSpamMetrics reports how many chirps got each spam decision, in the
+// Prometheus text format.
+func (cfg *apiConfig) handlerSpamMetrics(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintln(w, "# HELP chirpy_spam_decisions_total Chirps scored by the spam filter, by decision.")
+	fmt.Fprintln(w, "# TYPE chirpy_spam_decisions_total counter")
+	for _, decision := range spam.Decisions {
+		fmt.Fprintf(w, "chirpy_spam_decisions_total{decision=%q} %d\n", decision, cfg.spamDecisions[decision].Load())
+	}
+}
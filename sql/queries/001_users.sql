@@ -1,28 +1,53 @@
 -- name: CreateUser :one
-INSERT INTO users (id, created_at, updated_at, email, hashed_password)
+INSERT INTO users (id, created_at, updated_at, email, hashed_password, handle)
 VALUES (
     gen_random_uuid(),
     NOW(),
     NOW(),
     $1,
-    $2
+    $2,
+    $3
 )
 RETURNING *;
 
 -- name: DeleteUsers :exec
 DELETE FROM users;
 
+-- name: GetUser :one
+SELECT * FROM users
+WHERE id = $1;
+
 -- name: GetUserByEmail :one
 SELECT * FROM users
 WHERE email = $1;
 
 -- name: UpdateUser :one
 UPDATE users
-SET email = $1, hashed_password = $2, updated_at = NOW()
+SET email = sqlc.arg(email),
+    hashed_password = sqlc.arg(hashed_password),
+    handle = COALESCE(sqlc.narg(handle), handle),
+    updated_at = NOW()
+WHERE id = sqlc.arg(id)
+RETURNING *;
+
+-- name: UpdateUserPreferences :one
+UPDATE users
+SET expand_content_warnings = $1,
+    show_sensitive_media = $2,
+    updated_at = NOW()
+WHERE id = $3
+RETURNING *;
+
+-- name: UpdateUserPrivacy :one
+UPDATE users
+SET protected = $1,
+    dm_policy = $2,
+    updated_at = NOW()
 WHERE id = $3
 RETURNING *;
 
 -- name: UpgradeUser :one
 UPDATE users
 SET is_chirpy_red = true
+WHERE id = $1
 RETURNING id;
\ No newline at end of file
@@ -1,27 +1,32 @@
 -- name: CreateChirp :one
-INSERT INTO chirps (id, created_at, updated_at, body, user_id)
+INSERT INTO chirps (id, created_at, updated_at, body, user_id, publish_at, visibility, content_warning, in_reply_to_id)
 VALUES (
     gen_random_uuid(),
     NOW(),
     NOW(),
     $1,
-    $2
+    $2,
+    $3,
+    $4,
+    $5,
+    $6
 )
 RETURNING *;
 
--- name: GetAllChirps :many
-SELECT * FROM chirps
-ORDER BY created_at;
-
--- name: GetChirpsForUser :many
-SELECT * FROM chirps
-WHERE user_id = $1
-ORDER BY created_at;
-
 -- name: GetChirp :one
 SELECT * FROM chirps
 WHERE id = $1;
 
+-- name: CanViewChirp :one
+SELECT chirp_visible_to(id, user_id, visibility, sqlc.arg(viewer_id)::uuid) FROM chirps
+WHERE id = sqlc.arg(id);
+
 -- name: DeleteChirp :exec
 DELETE FROM chirps
-WHERE id = $1;
\ No newline at end of file
+WHERE id = $1;
+
+-- name: UpdateChirpBody :one
+UPDATE chirps
+SET body = $1, content_warning = $2, updated_at = NOW()
+WHERE id = $3
+RETURNING *;
\ No newline at end of file
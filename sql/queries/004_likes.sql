@@ -0,0 +1,37 @@
+-- name: LikeChirp :execrows
+INSERT INTO likes (user_id, chirp_id, created_at)
+VALUES (
+    $1,
+    $2,
+    NOW()
+)
+ON CONFLICT DO NOTHING;
+
+-- name: UnlikeChirp :execrows
+DELETE FROM likes
+WHERE user_id = $1 AND chirp_id = $2;
+
+-- name: IncrementLikeCount :exec
+UPDATE chirps
+SET like_count = like_count + 1
+WHERE id = $1;
+
+-- name: DecrementLikeCount :exec
+UPDATE chirps
+SET like_count = like_count - 1
+WHERE id = $1 AND like_count > 0;
+
+-- name: GetLikedChirpIDs :many
+SELECT chirp_id FROM likes
+WHERE user_id = sqlc.arg(user_id)
+AND chirp_id = ANY(sqlc.arg(chirp_ids)::uuid[]);
+
+-- name: GetLikedChirpsForUser :many
+SELECT chirps.* FROM chirps
+INNER JOIN likes ON chirps.id = likes.chirp_id
+WHERE likes.user_id = sqlc.arg(user_id)
+AND chirps.publish_at IS NULL
+AND chirps.deleted_at IS NULL
+AND chirps.hidden_at IS NULL
+AND chirp_visible_to(chirps.id, chirps.user_id, chirps.visibility, sqlc.arg(viewer_id)::uuid)
+ORDER BY likes.created_at DESC;
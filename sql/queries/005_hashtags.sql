@@ -0,0 +1,40 @@
+-- name: CreateChirpHashtag :exec
+INSERT INTO chirp_hashtags (chirp_id, tag, created_at)
+VALUES (
+    $1,
+    $2,
+    NOW()
+)
+ON CONFLICT DO NOTHING;
+
+-- name: GetChirpsForHashtag :many
+SELECT chirps.* FROM chirps
+INNER JOIN chirp_hashtags ON chirps.id = chirp_hashtags.chirp_id
+WHERE chirp_hashtags.tag = sqlc.arg(tag)
+AND chirps.publish_at IS NULL
+AND chirps.deleted_at IS NULL
+AND chirps.hidden_at IS NULL
+AND chirp_visible_to(chirps.id, chirps.user_id, chirps.visibility, sqlc.arg(viewer_id)::uuid)
+AND NOT muted_by(chirps.user_id, sqlc.arg(viewer_id)::uuid)
+ORDER BY chirps.created_at DESC;
+
+-- name: GetHashtagUsage :many
+SELECT chirp_hashtags.tag,
+    COUNT(*) FILTER (WHERE chirp_hashtags.created_at > sqlc.arg(recent_since)::timestamp) AS recent_uses,
+    COUNT(*) AS total_uses
+FROM chirp_hashtags
+INNER JOIN chirps ON chirps.id = chirp_hashtags.chirp_id
+INNER JOIN users ON users.id = chirps.user_id
+WHERE chirp_hashtags.created_at > sqlc.arg(baseline_since)::timestamp
+AND chirps.publish_at IS NULL
+AND chirps.deleted_at IS NULL
+AND chirps.hidden_at IS NULL
+AND chirps.visibility = 'public'
+AND NOT users.protected
+GROUP BY chirp_hashtags.tag;
+
+
+-- name: DeleteStaleChirpHashtags :exec
+DELETE FROM chirp_hashtags
+WHERE chirp_id = sqlc.arg(chirp_id)
+AND NOT (tag = ANY(sqlc.arg(tags)::text[]));
\ No newline at end of file
@@ -0,0 +1,35 @@
+-- name: GetUsersByHandles :many
+SELECT id, handle FROM users
+WHERE handle = ANY(sqlc.arg(handles)::text[]);
+
+-- name: CreateChirpMention :exec
+INSERT INTO chirp_mentions (chirp_id, user_id, start_offset, end_offset)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4
+);
+
+-- name: GetMentionsForChirps :many
+SELECT * FROM chirp_mentions
+WHERE chirp_id = ANY(sqlc.arg(chirp_ids)::uuid[])
+ORDER BY start_offset;
+
+-- name: GetChirpsMentioningUser :many
+SELECT * FROM chirps
+WHERE id IN (
+    SELECT chirp_id FROM chirp_mentions
+    WHERE chirp_mentions.user_id = $1
+)
+AND publish_at IS NULL
+AND deleted_at IS NULL
+AND hidden_at IS NULL
+AND chirp_visible_to(id, user_id, visibility, $1)
+AND NOT muted_by(user_id, $1)
+ORDER BY created_at DESC;
+
+
+-- name: DeleteChirpMentions :exec
+DELETE FROM chirp_mentions
+WHERE chirp_id = $1;
\ No newline at end of file
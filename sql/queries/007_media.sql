@@ -0,0 +1,32 @@
+-- name: CreateMediaFile :one
+INSERT INTO media_files (id, created_at, user_id, mime_type, thumbnail_mime_type, size_bytes, width, height, alt_text, sensitive)
+VALUES (
+    $1,
+    NOW(),
+    $2,
+    $3,
+    $4,
+    $5,
+    $6,
+    $7,
+    $8,
+    $9
+)
+RETURNING *;
+
+-- name: GetMediaFile :one
+SELECT * FROM media_files
+WHERE id = $1;
+
+-- name: AttachMediaFiles :many
+UPDATE media_files
+SET chirp_id = sqlc.arg(chirp_id)
+WHERE id = ANY(sqlc.arg(ids)::uuid[])
+AND user_id = sqlc.arg(user_id)
+AND chirp_id IS NULL
+RETURNING id;
+
+-- name: GetMediaFilesForChirps :many
+SELECT * FROM media_files
+WHERE chirp_id = ANY(sqlc.arg(chirp_ids)::uuid[])
+ORDER BY created_at;
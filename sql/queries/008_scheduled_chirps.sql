@@ -0,0 +1,28 @@
+-- name: GetScheduledChirpsForUser :many
+SELECT * FROM chirps
+WHERE user_id = $1
+AND publish_at IS NOT NULL
+AND deleted_at IS NULL
+ORDER BY publish_at;
+
+-- name: RescheduleChirp :one
+UPDATE chirps
+SET publish_at = $1, updated_at = NOW()
+WHERE id = $2
+AND user_id = $3
+AND publish_at IS NOT NULL
+AND deleted_at IS NULL
+RETURNING *;
+
+-- name: PublishDueChirps :many
+UPDATE chirps
+SET created_at = publish_at, updated_at = NOW(), publish_at = NULL
+WHERE publish_at IS NOT NULL
+AND publish_at <= NOW()
+AND deleted_at IS NULL
+RETURNING id, in_reply_to_id;
+
+-- name: TouchChirpHashtags :exec
+UPDATE chirp_hashtags
+SET created_at = NOW()
+WHERE chirp_id = ANY(sqlc.arg(chirp_ids)::uuid[]);
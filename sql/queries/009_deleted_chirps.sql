@@ -0,0 +1,22 @@
+-- name: SoftDeleteChirp :exec
+UPDATE chirps
+SET deleted_at = NOW(), updated_at = NOW(), pinned_at = NULL
+WHERE id = $1;
+
+-- name: RestoreChirp :one
+UPDATE chirps
+SET deleted_at = NULL, updated_at = NOW()
+WHERE id = sqlc.arg(id)
+AND user_id = sqlc.arg(user_id)
+AND deleted_at > sqlc.arg(deleted_after)::timestamp
+RETURNING *;
+
+-- name: GetDeletedChirpsForUser :many
+SELECT * FROM chirps
+WHERE user_id = $1
+AND deleted_at IS NOT NULL
+ORDER BY deleted_at DESC;
+
+-- name: GetChirpsToPurge :many
+SELECT id FROM chirps
+WHERE deleted_at < sqlc.arg(deleted_before)::timestamp;
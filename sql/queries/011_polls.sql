@@ -0,0 +1,48 @@
+-- name: CreatePoll :exec
+INSERT INTO polls (chirp_id, created_at, closes_at)
+VALUES (
+    $1,
+    NOW(),
+    $2
+);
+
+-- name: CreatePollOption :exec
+INSERT INTO poll_options (chirp_id, position, label)
+VALUES (
+    $1,
+    $2,
+    $3
+);
+
+-- name: GetPoll :one
+SELECT * FROM polls
+WHERE chirp_id = $1;
+
+-- name: GetPollsForChirps :many
+SELECT * FROM polls
+WHERE chirp_id = ANY(sqlc.arg(chirp_ids)::uuid[]);
+
+-- name: GetPollOptionsForChirps :many
+SELECT * FROM poll_options
+WHERE chirp_id = ANY(sqlc.arg(chirp_ids)::uuid[])
+ORDER BY chirp_id, position;
+
+-- name: GetPollVotesForUser :many
+SELECT chirp_id, position FROM poll_votes
+WHERE user_id = sqlc.arg(user_id)
+AND chirp_id = ANY(sqlc.arg(chirp_ids)::uuid[]);
+
+-- name: CreatePollVote :execrows
+INSERT INTO poll_votes (chirp_id, user_id, position, created_at)
+VALUES (
+    $1,
+    $2,
+    $3,
+    NOW()
+)
+ON CONFLICT DO NOTHING;
+
+-- name: IncrementPollOptionVotes :execrows
+UPDATE poll_options
+SET vote_count = vote_count + 1
+WHERE chirp_id = $1 AND position = $2;
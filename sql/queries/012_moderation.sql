@@ -0,0 +1,33 @@
+-- name: GetModerationWords :many
+SELECT * FROM moderation_words
+ORDER BY word;
+
+-- name: CreateModerationWord :one
+INSERT INTO moderation_words (id, created_at, updated_at, word, action, reason)
+VALUES (
+    gen_random_uuid(),
+    NOW(),
+    NOW(),
+    $1,
+    $2,
+    $3
+)
+RETURNING *;
+
+-- name: DeleteModerationWord :execrows
+DELETE FROM moderation_words
+WHERE id = $1;
+
+-- name: CreateChirpFlag :exec
+INSERT INTO chirp_flags (id, chirp_id, created_at, filter, reason)
+VALUES (
+    gen_random_uuid(),
+    $1,
+    NOW(),
+    $2,
+    $3
+);
+
+-- name: GetChirpFlags :many
+SELECT * FROM chirp_flags
+ORDER BY created_at DESC;
@@ -0,0 +1,85 @@
+-- name: CreateReport :one
+INSERT INTO reports (id, created_at, updated_at, reporter_id, reported_user_id, chirp_id, reason, details)
+VALUES (
+    gen_random_uuid(),
+    NOW(),
+    NOW(),
+    $1,
+    $2,
+    $3,
+    $4,
+    $5
+)
+RETURNING *;
+
+-- name: GetReport :one
+SELECT * FROM reports
+WHERE id = $1;
+
+-- name: GetReportsForReporter :many
+SELECT * FROM reports
+WHERE reporter_id = $1
+ORDER BY created_at DESC;
+
+-- name: GetReportQueue :many
+SELECT * FROM reports
+WHERE (sqlc.narg(status)::text IS NULL OR status = sqlc.narg(status))
+AND (sqlc.narg(reason)::text IS NULL OR reason = sqlc.narg(reason))
+AND (sqlc.narg(assignee_id)::uuid IS NULL OR assignee_id = sqlc.narg(assignee_id))
+AND (NOT sqlc.arg(unassigned)::boolean OR assignee_id IS NULL)
+ORDER BY created_at;
+
+-- name: AssignReport :one
+UPDATE reports
+SET assignee_id = $1, updated_at = NOW()
+WHERE id = $2
+AND status = 'open'
+RETURNING *;
+
+-- name: ResolveReport :one
+UPDATE reports
+SET status = 'resolved', resolution = $1, resolved_at = NOW(), updated_at = NOW()
+WHERE id = $2
+AND status = 'open'
+RETURNING *;
+
+-- name: CreateReportDecision :exec
+INSERT INTO report_decisions (id, created_at, report_id, moderator_id, action, note)
+VALUES (
+    gen_random_uuid(),
+    NOW(),
+    $1,
+    $2,
+    $3,
+    $4
+);
+
+-- name: GetReportDecisions :many
+SELECT * FROM report_decisions
+WHERE report_id = $1
+ORDER BY created_at;
+
+-- name: HideChirp :exec
+UPDATE chirps
+SET hidden_at = NOW(), updated_at = NOW()
+WHERE id = $1
+AND hidden_at IS NULL;
+
+-- name: SuspendUser :exec
+UPDATE users
+SET suspended_at = NOW(), updated_at = NOW()
+WHERE id = $1
+AND suspended_at IS NULL;
+
+-- name: RevokeTokensForUser :exec
+UPDATE refresh_tokens
+SET revoked_at = NOW(), updated_at = NOW()
+WHERE user_id = $1
+AND revoked_at IS NULL;
+
+-- name: UnhideChirp :one
+UPDATE chirps
+SET hidden_at = NULL, updated_at = NOW()
+WHERE id = $1
+AND hidden_at IS NOT NULL
+RETURNING *;
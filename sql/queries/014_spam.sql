@@ -0,0 +1,15 @@
+-- name: CountRecentChirpsForUser :one
+-- id is left out, so that a chirp being edited isn't compared with itself
+SELECT COUNT(*) FROM chirps
+WHERE user_id = $1
+AND created_at > $2
+AND id <> $3;
+
+-- name: GetRecentChirpBodiesForUser :many
+-- id is left out, so that a chirp being edited isn't compared with itself
+SELECT body FROM chirps
+WHERE user_id = $1
+AND created_at > $2
+AND id <> $3
+ORDER BY created_at DESC
+LIMIT $4;
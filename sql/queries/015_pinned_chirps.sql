@@ -0,0 +1,16 @@
+-- name: PinChirp :one
+UPDATE chirps
+SET pinned_at = NOW()
+WHERE id = sqlc.arg(id)
+AND (
+    SELECT COUNT(*) FROM chirps
+    WHERE user_id = sqlc.arg(user_id)
+    AND pinned_at IS NOT NULL
+) < sqlc.arg(max_pinned)::int
+RETURNING *;
+
+-- name: UnpinChirp :one
+UPDATE chirps
+SET pinned_at = NULL
+WHERE id = $1
+RETURNING *;
@@ -0,0 +1,61 @@
+-- name: CreateBookmarkFolder :one
+INSERT INTO bookmark_folders (id, created_at, updated_at, user_id, name)
+VALUES (
+    gen_random_uuid(),
+    NOW(),
+    NOW(),
+    $1,
+    $2
+)
+RETURNING *;
+
+-- name: GetBookmarkFolder :one
+SELECT * FROM bookmark_folders
+WHERE id = $1 AND user_id = $2;
+
+-- name: GetBookmarkFolders :many
+SELECT * FROM bookmark_folders
+WHERE user_id = $1
+ORDER BY name;
+
+-- name: RenameBookmarkFolder :one
+UPDATE bookmark_folders
+SET name = $1, updated_at = NOW()
+WHERE id = $2 AND user_id = $3
+RETURNING *;
+
+-- name: DeleteBookmarkFolder :execrows
+DELETE FROM bookmark_folders
+WHERE id = $1 AND user_id = $2;
+
+-- name: SaveBookmark :one
+INSERT INTO bookmarks (user_id, chirp_id, folder_id, created_at)
+VALUES (
+    $1,
+    $2,
+    $3,
+    NOW()
+)
+ON CONFLICT (user_id, chirp_id) DO UPDATE
+SET folder_id = EXCLUDED.folder_id
+RETURNING *;
+
+-- name: DeleteBookmark :execrows
+DELETE FROM bookmarks
+WHERE user_id = $1 AND chirp_id = $2;
+
+-- name: GetBookmarks :many
+SELECT sqlc.embed(chirps), bookmarks.folder_id, bookmarks.created_at AS bookmarked_at FROM bookmarks
+INNER JOIN chirps ON chirps.id = bookmarks.chirp_id
+WHERE bookmarks.user_id = sqlc.arg(user_id)
+AND (NOT sqlc.arg(filter_folder)::boolean OR bookmarks.folder_id IS NOT DISTINCT FROM sqlc.narg(folder_id)::uuid)
+AND (
+    sqlc.narg(before_created_at)::timestamp IS NULL
+    OR (bookmarks.created_at, bookmarks.chirp_id) < (sqlc.narg(before_created_at)::timestamp, sqlc.narg(before_chirp_id)::uuid)
+)
+AND chirps.publish_at IS NULL
+AND chirps.deleted_at IS NULL
+AND chirps.hidden_at IS NULL
+AND chirp_visible_to(chirps.id, chirps.user_id, chirps.visibility, sqlc.arg(user_id))
+ORDER BY bookmarks.created_at DESC, bookmarks.chirp_id DESC
+LIMIT sqlc.arg(page_size);
@@ -0,0 +1,10 @@
+-- name: SetContentWarning :one
+UPDATE chirps
+SET content_warning = $1, content_warning_forced = $2, updated_at = NOW()
+WHERE id = $3
+RETURNING *;
+
+-- name: MarkChirpMediaSensitive :exec
+UPDATE media_files
+SET sensitive = true
+WHERE chirp_id = $1;
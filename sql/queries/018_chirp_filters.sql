@@ -0,0 +1,36 @@
+-- name: FilterChirps :many
+SELECT * FROM chirps
+WHERE publish_at IS NULL
+AND deleted_at IS NULL
+AND hidden_at IS NULL
+AND chirp_visible_to(id, user_id, visibility, sqlc.arg(viewer_id)::uuid)
+AND (cardinality(sqlc.arg(author_ids)::uuid[]) = 0 OR user_id = ANY(sqlc.arg(author_ids)::uuid[]))
+AND NOT (user_id = ANY(sqlc.arg(exclude_author_ids)::uuid[]))
+-- Muted accounts are left out unless they were asked for by author_id
+AND (user_id = ANY(sqlc.arg(author_ids)::uuid[]) OR NOT muted_by(user_id, sqlc.arg(viewer_id)::uuid))
+AND (sqlc.narg(since)::timestamp IS NULL OR created_at > sqlc.narg(since))
+AND (sqlc.narg(until)::timestamp IS NULL OR created_at < sqlc.narg(until))
+AND (
+    sqlc.narg(since_id_created_at)::timestamp IS NULL
+    OR (created_at, id) > (sqlc.narg(since_id_created_at)::timestamp, sqlc.narg(since_id)::uuid)
+)
+AND (
+    sqlc.narg(max_id_created_at)::timestamp IS NULL
+    OR (created_at, id) <= (sqlc.narg(max_id_created_at)::timestamp, sqlc.narg(max_id)::uuid)
+)
+AND (
+    sqlc.narg(has_media)::boolean IS NULL
+    OR EXISTS (SELECT 1 FROM media_files WHERE media_files.chirp_id = chirps.id) = sqlc.narg(has_media)
+)
+-- Matches the links found by entities.ParseURLs: http(s):// at the start of
+-- a word, followed by something other than trailing punctuation
+AND (
+    sqlc.narg(has_links)::boolean IS NULL
+    OR (body ~ '(^|[[:space:](])https?://[^[:space:]]*[^[:space:].,!?;:)''"]') = sqlc.narg(has_links)
+)
+AND (sqlc.narg(is_reply)::boolean IS NULL OR (in_reply_to_id IS NOT NULL) = sqlc.narg(is_reply))
+AND (sqlc.narg(list_id)::uuid IS NULL OR user_id IN (
+    SELECT list_members.user_id FROM list_members
+    WHERE list_members.list_id = sqlc.narg(list_id)
+))
+ORDER BY CASE WHEN sqlc.arg(pinned_first)::boolean THEN pinned_at END DESC NULLS LAST, created_at, id;
@@ -0,0 +1,19 @@
+-- name: GetChirpsForExport :many
+SELECT * FROM chirps
+WHERE user_id = sqlc.arg(user_id)
+AND deleted_at IS NULL
+AND hidden_at IS NULL
+AND (
+    sqlc.narg(after_created_at)::timestamp IS NULL
+    OR (created_at, id) > (sqlc.narg(after_created_at)::timestamp, sqlc.narg(after_id)::uuid)
+)
+ORDER BY created_at, id
+LIMIT sqlc.arg(batch_size);
+
+-- name: GetExistingChirpIDs :many
+SELECT id FROM chirps
+WHERE id = ANY(sqlc.arg(ids)::uuid[]);
+
+-- name: GetExistingUserIDs :many
+SELECT id FROM users
+WHERE id = ANY(sqlc.arg(ids)::uuid[]);
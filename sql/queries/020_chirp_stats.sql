@@ -0,0 +1,48 @@
+-- name: AddChirpStats :exec
+-- Counts for chirps that have been purged since they were recorded are
+-- dropped by the join
+INSERT INTO chirp_stats_hourly (chirp_id, user_id, hour, impressions, views, likes, replies)
+SELECT chirps.id, chirps.user_id, stats.hour, stats.impressions, stats.views, stats.likes, stats.replies
+FROM unnest(
+    sqlc.arg(chirp_ids)::uuid[],
+    sqlc.arg(hours)::timestamp[],
+    sqlc.arg(impressions)::bigint[],
+    sqlc.arg(views)::bigint[],
+    sqlc.arg(likes)::bigint[],
+    sqlc.arg(replies)::bigint[]
+) AS stats (chirp_id, hour, impressions, views, likes, replies)
+INNER JOIN chirps ON chirps.id = stats.chirp_id
+ON CONFLICT (chirp_id, hour) DO UPDATE
+SET impressions = chirp_stats_hourly.impressions + EXCLUDED.impressions,
+    views = chirp_stats_hourly.views + EXCLUDED.views,
+    likes = chirp_stats_hourly.likes + EXCLUDED.likes,
+    replies = chirp_stats_hourly.replies + EXCLUDED.replies;
+
+-- name: GetUserStatsSeries :many
+SELECT
+    date_trunc(sqlc.arg(unit)::text, hour)::timestamp AS bucket,
+    SUM(impressions)::bigint AS impressions,
+    SUM(views)::bigint AS views,
+    SUM(likes)::bigint AS likes,
+    SUM(replies)::bigint AS replies
+FROM chirp_stats_hourly
+WHERE user_id = sqlc.arg(user_id)
+AND hour >= sqlc.arg(since)
+AND hour < sqlc.arg(until)
+GROUP BY bucket
+ORDER BY bucket;
+
+-- name: GetTopChirpStats :many
+SELECT
+    chirp_id,
+    SUM(impressions)::bigint AS impressions,
+    SUM(views)::bigint AS views,
+    SUM(likes)::bigint AS likes,
+    SUM(replies)::bigint AS replies
+FROM chirp_stats_hourly
+WHERE user_id = sqlc.arg(user_id)
+AND hour >= sqlc.arg(since)
+AND hour < sqlc.arg(until)
+GROUP BY chirp_id
+ORDER BY impressions DESC, chirp_id
+LIMIT sqlc.arg(row_limit);
@@ -0,0 +1,48 @@
+-- name: FollowUser :execrows
+INSERT INTO follows (follower_id, followee_id, created_at)
+VALUES ($1, $2, NOW())
+ON CONFLICT DO NOTHING;
+
+-- name: UnfollowUser :execrows
+DELETE FROM follows
+WHERE follower_id = $1 AND followee_id = $2;
+
+-- name: IsFollowing :one
+SELECT EXISTS (
+    SELECT 1 FROM follows
+    WHERE follower_id = $1 AND followee_id = $2
+);
+
+-- name: IncrementFollowCounts :exec
+UPDATE users
+SET follower_count = follower_count + CASE WHEN id = sqlc.arg(followee_id) THEN 1 ELSE 0 END,
+    following_count = following_count + CASE WHEN id = sqlc.arg(follower_id) THEN 1 ELSE 0 END
+WHERE id IN (sqlc.arg(follower_id), sqlc.arg(followee_id));
+
+-- name: DecrementFollowCounts :exec
+UPDATE users
+SET follower_count = GREATEST(follower_count - CASE WHEN id = sqlc.arg(followee_id) THEN 1 ELSE 0 END, 0),
+    following_count = GREATEST(following_count - CASE WHEN id = sqlc.arg(follower_id) THEN 1 ELSE 0 END, 0)
+WHERE id IN (sqlc.arg(follower_id), sqlc.arg(followee_id));
+
+-- name: GetFollowers :many
+SELECT sqlc.embed(users), follows.created_at AS followed_at FROM follows
+INNER JOIN users ON users.id = follows.follower_id
+WHERE follows.followee_id = sqlc.arg(user_id)
+AND (
+    sqlc.narg(before_created_at)::timestamp IS NULL
+    OR (follows.created_at, follows.follower_id) < (sqlc.narg(before_created_at)::timestamp, sqlc.narg(before_user_id)::uuid)
+)
+ORDER BY follows.created_at DESC, follows.follower_id DESC
+LIMIT sqlc.arg(page_size);
+
+-- name: GetFollowing :many
+SELECT sqlc.embed(users), follows.created_at AS followed_at FROM follows
+INNER JOIN users ON users.id = follows.followee_id
+WHERE follows.follower_id = sqlc.arg(user_id)
+AND (
+    sqlc.narg(before_created_at)::timestamp IS NULL
+    OR (follows.created_at, follows.followee_id) < (sqlc.narg(before_created_at)::timestamp, sqlc.narg(before_user_id)::uuid)
+)
+ORDER BY follows.created_at DESC, follows.followee_id DESC
+LIMIT sqlc.arg(page_size);
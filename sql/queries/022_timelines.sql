@@ -0,0 +1,73 @@
+-- name: FanOutChirps :exec
+-- Adds chirps to their authors' timelines, and to their followers' unless
+-- the author has more than max_followers followers. Those authors' chirps
+-- are read from chirps by GetHomeTimeline instead
+INSERT INTO timeline_entries (user_id, chirp_id, author_id, created_at)
+SELECT chirps.user_id, chirps.id, chirps.user_id, chirps.created_at FROM chirps
+WHERE chirps.id = ANY(sqlc.arg(chirp_ids)::uuid[])
+UNION ALL
+SELECT follows.follower_id, chirps.id, chirps.user_id, chirps.created_at FROM chirps
+INNER JOIN users ON users.id = chirps.user_id
+INNER JOIN follows ON follows.followee_id = chirps.user_id
+WHERE chirps.id = ANY(sqlc.arg(chirp_ids)::uuid[])
+AND users.follower_count <= sqlc.arg(max_followers)
+ON CONFLICT DO NOTHING;
+
+-- name: BackfillTimeline :exec
+-- Adds an author's most recent chirps to a new follower's timeline
+INSERT INTO timeline_entries (user_id, chirp_id, author_id, created_at)
+SELECT sqlc.arg(user_id)::uuid, chirps.id, chirps.user_id, chirps.created_at FROM chirps
+INNER JOIN users ON users.id = chirps.user_id
+WHERE chirps.user_id = sqlc.arg(author_id)
+AND users.follower_count <= sqlc.arg(max_followers)
+AND chirps.publish_at IS NULL
+AND chirps.deleted_at IS NULL
+ORDER BY chirps.created_at DESC
+LIMIT sqlc.arg(backfill_size)
+ON CONFLICT DO NOTHING;
+
+-- name: RemoveAuthorFromTimeline :exec
+DELETE FROM timeline_entries
+WHERE user_id = $1 AND author_id = $2;
+
+-- name: GetHomeTimeline :many
+-- Merges the caller's fanned-out timeline with recent chirps from the large
+-- accounts they follow. Each branch is limited on its own so that both can
+-- walk an index
+(
+    SELECT chirps.* FROM timeline_entries
+    INNER JOIN chirps ON chirps.id = timeline_entries.chirp_id
+    WHERE timeline_entries.user_id = sqlc.arg(user_id)
+    AND (
+        sqlc.narg(before_created_at)::timestamp IS NULL
+        OR (timeline_entries.created_at, timeline_entries.chirp_id) < (sqlc.narg(before_created_at)::timestamp, sqlc.narg(before_chirp_id)::uuid)
+    )
+    AND chirps.publish_at IS NULL
+    AND chirps.deleted_at IS NULL
+    AND chirps.hidden_at IS NULL
+    AND chirp_visible_to(chirps.id, chirps.user_id, chirps.visibility, sqlc.arg(user_id))
+    AND NOT muted_by(chirps.user_id, sqlc.arg(user_id))
+    ORDER BY timeline_entries.created_at DESC, timeline_entries.chirp_id DESC
+    LIMIT sqlc.arg(page_size)
+)
+UNION
+(
+    SELECT chirps.* FROM follows
+    INNER JOIN users ON users.id = follows.followee_id
+    INNER JOIN chirps ON chirps.user_id = follows.followee_id
+    WHERE follows.follower_id = sqlc.arg(user_id)
+    AND users.follower_count > sqlc.arg(max_followers)
+    AND (
+        sqlc.narg(before_created_at)::timestamp IS NULL
+        OR (chirps.created_at, chirps.id) < (sqlc.narg(before_created_at)::timestamp, sqlc.narg(before_chirp_id)::uuid)
+    )
+    AND chirps.publish_at IS NULL
+    AND chirps.deleted_at IS NULL
+    AND chirps.hidden_at IS NULL
+    AND chirp_visible_to(chirps.id, chirps.user_id, chirps.visibility, sqlc.arg(user_id))
+    AND NOT muted_by(chirps.user_id, sqlc.arg(user_id))
+    ORDER BY chirps.created_at DESC, chirps.id DESC
+    LIMIT sqlc.arg(page_size)
+)
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg(page_size);
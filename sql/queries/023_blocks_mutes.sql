@@ -0,0 +1,69 @@
+-- name: BlockUser :one
+-- Blocking someone already blocked keeps the original block
+INSERT INTO blocks (blocker_id, blocked_id, created_at)
+VALUES ($1, $2, NOW())
+ON CONFLICT (blocker_id, blocked_id) DO UPDATE
+SET created_at = blocks.created_at
+RETURNING *;
+
+-- name: UnblockUser :exec
+DELETE FROM blocks
+WHERE blocker_id = $1 AND blocked_id = $2;
+
+-- name: BlockExists :one
+-- Whether either user has blocked the other
+SELECT EXISTS (
+    SELECT 1 FROM blocks
+    WHERE (blocker_id = sqlc.arg(user_id) AND blocked_id = sqlc.arg(other_id))
+    OR (blocker_id = sqlc.arg(other_id) AND blocked_id = sqlc.arg(user_id))
+);
+
+-- name: GetBlockedUserIDs :many
+-- Returns the users in user_ids that have blocked user_id or been blocked
+-- by them
+SELECT blocked_id AS id FROM blocks
+WHERE blocker_id = sqlc.arg(user_id)
+AND blocked_id = ANY(sqlc.arg(user_ids)::uuid[])
+UNION
+SELECT blocker_id FROM blocks
+WHERE blocked_id = sqlc.arg(user_id)
+AND blocker_id = ANY(sqlc.arg(user_ids)::uuid[]);
+
+-- name: GetBlocks :many
+SELECT sqlc.embed(users), blocks.created_at AS blocked_at FROM blocks
+INNER JOIN users ON users.id = blocks.blocked_id
+WHERE blocks.blocker_id = sqlc.arg(user_id)
+AND (
+    sqlc.narg(before_created_at)::timestamp IS NULL
+    OR (blocks.created_at, blocks.blocked_id) < (sqlc.narg(before_created_at)::timestamp, sqlc.narg(before_user_id)::uuid)
+)
+ORDER BY blocks.created_at DESC, blocks.blocked_id DESC
+LIMIT sqlc.arg(page_size);
+
+-- name: MuteUser :one
+-- Muting someone already muted starts the mute again with the new expiry
+INSERT INTO mutes (muter_id, muted_id, created_at, expires_at)
+VALUES ($1, $2, NOW(), $3)
+ON CONFLICT (muter_id, muted_id) DO UPDATE
+SET created_at = NOW(), expires_at = EXCLUDED.expires_at
+RETURNING *;
+
+-- name: UnmuteUser :exec
+DELETE FROM mutes
+WHERE muter_id = $1 AND muted_id = $2;
+
+-- name: GetMutes :many
+SELECT sqlc.embed(users), mutes.created_at AS muted_at, mutes.expires_at FROM mutes
+INNER JOIN users ON users.id = mutes.muted_id
+WHERE mutes.muter_id = sqlc.arg(user_id)
+AND (mutes.expires_at IS NULL OR mutes.expires_at > NOW())
+AND (
+    sqlc.narg(before_created_at)::timestamp IS NULL
+    OR (mutes.created_at, mutes.muted_id) < (sqlc.narg(before_created_at)::timestamp, sqlc.narg(before_user_id)::uuid)
+)
+ORDER BY mutes.created_at DESC, mutes.muted_id DESC
+LIMIT sqlc.arg(page_size);
+
+-- name: DeleteExpiredMutes :execrows
+DELETE FROM mutes
+WHERE expires_at <= NOW();
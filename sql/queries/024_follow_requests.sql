@@ -0,0 +1,27 @@
+-- name: CreateFollowRequest :one
+-- Asking again keeps the original request
+INSERT INTO follow_requests (requester_id, target_id, created_at)
+VALUES ($1, $2, NOW())
+ON CONFLICT (requester_id, target_id) DO UPDATE
+SET created_at = follow_requests.created_at
+RETURNING *;
+
+-- name: DeleteFollowRequest :execrows
+DELETE FROM follow_requests
+WHERE requester_id = $1 AND target_id = $2;
+
+-- name: DeleteFollowRequestsForTarget :many
+DELETE FROM follow_requests
+WHERE target_id = $1
+RETURNING requester_id;
+
+-- name: GetFollowRequests :many
+SELECT sqlc.embed(users), follow_requests.created_at AS requested_at FROM follow_requests
+INNER JOIN users ON users.id = follow_requests.requester_id
+WHERE follow_requests.target_id = sqlc.arg(user_id)
+AND (
+    sqlc.narg(before_created_at)::timestamp IS NULL
+    OR (follow_requests.created_at, follow_requests.requester_id) < (sqlc.narg(before_created_at)::timestamp, sqlc.narg(before_user_id)::uuid)
+)
+ORDER BY follow_requests.created_at DESC, follow_requests.requester_id DESC
+LIMIT sqlc.arg(page_size);
@@ -0,0 +1,92 @@
+-- name: CreateList :one
+INSERT INTO lists (id, created_at, updated_at, owner_id, name, visibility)
+VALUES (
+    gen_random_uuid(),
+    NOW(),
+    NOW(),
+    $1,
+    $2,
+    $3
+)
+RETURNING *;
+
+-- name: GetList :one
+SELECT * FROM lists
+WHERE id = $1;
+
+-- name: UpdateList :one
+UPDATE lists
+SET name = $1,
+    visibility = $2,
+    updated_at = NOW()
+WHERE id = $3
+RETURNING *;
+
+-- name: DeleteList :exec
+DELETE FROM lists
+WHERE id = $1;
+
+-- name: GetUserLists :many
+-- Private lists are only included when include_private is set, for their
+-- owner
+SELECT * FROM lists
+WHERE owner_id = sqlc.arg(owner_id)
+AND (sqlc.arg(include_private)::boolean OR visibility = 'public')
+AND (
+    sqlc.narg(before_created_at)::timestamp IS NULL
+    OR (created_at, id) < (sqlc.narg(before_created_at)::timestamp, sqlc.narg(before_list_id)::uuid)
+)
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg(page_size);
+
+-- name: AddListMember :execrows
+INSERT INTO list_members (list_id, user_id, created_at)
+VALUES ($1, $2, NOW())
+ON CONFLICT DO NOTHING;
+
+-- name: RemoveListMember :execrows
+DELETE FROM list_members
+WHERE list_id = $1 AND user_id = $2;
+
+-- name: UpdateListMemberCount :exec
+UPDATE lists
+SET member_count = GREATEST(member_count + sqlc.arg(delta)::integer, 0)
+WHERE id = sqlc.arg(id);
+
+-- name: GetListMembers :many
+SELECT sqlc.embed(users), list_members.created_at AS added_at FROM list_members
+INNER JOIN users ON users.id = list_members.user_id
+WHERE list_members.list_id = sqlc.arg(list_id)
+AND (
+    sqlc.narg(before_created_at)::timestamp IS NULL
+    OR (list_members.created_at, list_members.user_id) < (sqlc.narg(before_created_at)::timestamp, sqlc.narg(before_user_id)::uuid)
+)
+ORDER BY list_members.created_at DESC, list_members.user_id DESC
+LIMIT sqlc.arg(page_size);
+
+-- name: SubscribeToList :execrows
+INSERT INTO list_subscriptions (list_id, user_id, created_at)
+VALUES ($1, $2, NOW())
+ON CONFLICT DO NOTHING;
+
+-- name: UnsubscribeFromList :execrows
+DELETE FROM list_subscriptions
+WHERE list_id = $1 AND user_id = $2;
+
+-- name: UpdateListSubscriberCount :exec
+UPDATE lists
+SET subscriber_count = GREATEST(subscriber_count + sqlc.arg(delta)::integer, 0)
+WHERE id = sqlc.arg(id);
+
+-- name: GetSubscribedLists :many
+-- Lists made private after being subscribed to are left out
+SELECT sqlc.embed(lists), list_subscriptions.created_at AS subscribed_at FROM list_subscriptions
+INNER JOIN lists ON lists.id = list_subscriptions.list_id
+WHERE list_subscriptions.user_id = sqlc.arg(user_id)
+AND lists.visibility = 'public'
+AND (
+    sqlc.narg(before_created_at)::timestamp IS NULL
+    OR (list_subscriptions.created_at, list_subscriptions.list_id) < (sqlc.narg(before_created_at)::timestamp, sqlc.narg(before_list_id)::uuid)
+)
+ORDER BY list_subscriptions.created_at DESC, list_subscriptions.list_id DESC
+LIMIT sqlc.arg(page_size);
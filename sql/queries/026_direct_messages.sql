@@ -0,0 +1,111 @@
+-- name: CreateConversation :one
+-- A one-to-one conversation created at the same time by both participants
+-- is only created once
+INSERT INTO conversations (id, created_at, creator_id, is_group, direct_key, last_message_at)
+VALUES (
+    gen_random_uuid(),
+    NOW(),
+    $1,
+    $2,
+    $3,
+    NOW()
+)
+ON CONFLICT (direct_key) DO UPDATE
+SET direct_key = EXCLUDED.direct_key
+RETURNING *;
+
+-- name: GetDirectConversation :one
+SELECT * FROM conversations
+WHERE direct_key = $1;
+
+-- name: AddConversationParticipants :exec
+INSERT INTO conversation_participants (conversation_id, user_id, joined_at)
+SELECT sqlc.arg(conversation_id)::uuid, unnest(sqlc.arg(user_ids)::uuid[]), NOW()
+ON CONFLICT DO NOTHING;
+
+-- name: GetConversationParticipants :many
+SELECT conversation_participants.conversation_id, sqlc.embed(users), conversation_participants.last_read_at FROM conversation_participants
+INNER JOIN users ON users.id = conversation_participants.user_id
+WHERE conversation_participants.conversation_id = ANY(sqlc.arg(conversation_ids)::uuid[])
+ORDER BY conversation_participants.joined_at, users.id;
+
+-- name: GetUserConversation :one
+-- Only finds the conversation if user_id takes part in it
+SELECT sqlc.embed(conversations), conversation_participants.last_read_at, conversation_participants.muted,
+    (
+        SELECT COUNT(*) FROM messages
+        WHERE messages.conversation_id = conversations.id
+        AND messages.sender_id <> conversation_participants.user_id
+        AND (conversation_participants.last_read_at IS NULL OR messages.created_at > conversation_participants.last_read_at)
+    ) AS unread_count
+FROM conversations
+INNER JOIN conversation_participants ON conversation_participants.conversation_id = conversations.id
+WHERE conversations.id = sqlc.arg(id)
+AND conversation_participants.user_id = sqlc.arg(user_id);
+
+-- name: GetUserConversations :many
+SELECT sqlc.embed(conversations), conversation_participants.last_read_at, conversation_participants.muted,
+    (
+        SELECT COUNT(*) FROM messages
+        WHERE messages.conversation_id = conversations.id
+        AND messages.sender_id <> conversation_participants.user_id
+        AND (conversation_participants.last_read_at IS NULL OR messages.created_at > conversation_participants.last_read_at)
+    ) AS unread_count
+FROM conversations
+INNER JOIN conversation_participants ON conversation_participants.conversation_id = conversations.id
+WHERE conversation_participants.user_id = sqlc.arg(user_id)
+AND (
+    sqlc.narg(before_last_message_at)::timestamp IS NULL
+    OR (conversations.last_message_at, conversations.id) < (sqlc.narg(before_last_message_at)::timestamp, sqlc.narg(before_conversation_id)::uuid)
+)
+ORDER BY conversations.last_message_at DESC, conversations.id DESC
+LIMIT sqlc.arg(page_size);
+
+-- name: CountUnreadConversations :one
+-- Muted conversations aren't counted
+SELECT COUNT(*) FROM conversation_participants
+WHERE conversation_participants.user_id = $1
+AND NOT conversation_participants.muted
+AND EXISTS (
+    SELECT 1 FROM messages
+    WHERE messages.conversation_id = conversation_participants.conversation_id
+    AND messages.sender_id <> conversation_participants.user_id
+    AND (conversation_participants.last_read_at IS NULL OR messages.created_at > conversation_participants.last_read_at)
+);
+
+-- name: MarkConversationRead :exec
+-- Read receipts only move forwards
+UPDATE conversation_participants
+SET last_read_at = GREATEST(last_read_at, sqlc.arg(read_at)::timestamp)
+WHERE conversation_id = sqlc.arg(conversation_id)
+AND user_id = sqlc.arg(user_id);
+
+-- name: SetConversationMuted :exec
+UPDATE conversation_participants
+SET muted = $1
+WHERE conversation_id = $2 AND user_id = $3;
+
+-- name: CreateMessage :one
+INSERT INTO messages (id, conversation_id, sender_id, created_at, body)
+VALUES ($1, $2, $3, NOW(), $4)
+RETURNING *;
+
+-- name: TouchConversation :exec
+UPDATE conversations
+SET last_message_at = $1
+WHERE id = $2;
+
+-- name: GetMessages :many
+SELECT * FROM messages
+WHERE conversation_id = sqlc.arg(conversation_id)
+AND (
+    sqlc.narg(before_created_at)::timestamp IS NULL
+    OR (created_at, id) < (sqlc.narg(before_created_at)::timestamp, sqlc.narg(before_message_id)::uuid)
+)
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg(page_size);
+
+-- name: DeleteMessage :execrows
+-- Only the sender can delete a message, which removes it for everyone
+DELETE FROM messages
+WHERE id = $1 AND conversation_id = $2 AND sender_id = $3;
@@ -0,0 +1,18 @@
+-- +goose Up
+CREATE TABLE media_files(
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    chirp_id UUID REFERENCES chirps (id) ON DELETE CASCADE,
+    mime_type TEXT NOT NULL,
+    thumbnail_mime_type TEXT NOT NULL,
+    size_bytes INTEGER NOT NULL,
+    width INTEGER NOT NULL,
+    height INTEGER NOT NULL,
+    alt_text TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX media_files_chirp_id_idx ON media_files (chirp_id);
+
+-- +goose Down
+DROP TABLE media_files;
@@ -0,0 +1,9 @@
+-- +goose Up
+ALTER TABLE chirps
+ADD publish_at TIMESTAMP;
+
+CREATE INDEX chirps_publish_at_idx ON chirps (publish_at) WHERE publish_at IS NOT NULL;
+
+-- +goose Down
+ALTER TABLE chirps
+DROP COLUMN publish_at;
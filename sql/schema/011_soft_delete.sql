@@ -0,0 +1,15 @@
+-- +goose Up
+ALTER TABLE chirps
+ADD deleted_at TIMESTAMP;
+
+CREATE INDEX chirps_deleted_at_idx ON chirps (deleted_at) WHERE deleted_at IS NOT NULL;
+
+ALTER TABLE users
+ADD is_moderator BOOLEAN NOT NULL DEFAULT false;
+
+-- +goose Down
+ALTER TABLE users
+DROP COLUMN is_moderator;
+
+ALTER TABLE chirps
+DROP COLUMN deleted_at;
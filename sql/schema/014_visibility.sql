@@ -0,0 +1,27 @@
+-- +goose Up
+ALTER TABLE chirps
+ADD visibility TEXT NOT NULL DEFAULT 'public'
+CHECK (visibility IN ('public', 'followers', 'mentioned'));
+
+-- chirp_visible_to is the single definition of who may read a chirp. Every
+-- query that lists chirps filters on it so the rule can't drift between
+-- endpoints. Followers-only chirps are visible to their author alone until
+-- there is a follow graph to check against.
+-- +goose StatementBegin
+CREATE FUNCTION chirp_visible_to(target_chirp UUID, author UUID, chirp_visibility TEXT, viewer UUID)
+RETURNS BOOLEAN AS $$
+    SELECT chirp_visibility = 'public'
+        OR author = viewer
+        OR (chirp_visibility = 'mentioned' AND EXISTS (
+            SELECT 1 FROM chirp_mentions
+            WHERE chirp_mentions.chirp_id = target_chirp
+            AND chirp_mentions.user_id = viewer
+        ));
+$$ LANGUAGE sql STABLE;
+-- +goose StatementEnd
+
+-- +goose Down
+DROP FUNCTION chirp_visible_to;
+
+ALTER TABLE chirps
+DROP COLUMN visibility;
@@ -0,0 +1,29 @@
+-- +goose Up
+CREATE TABLE moderation_words(
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    word TEXT NOT NULL UNIQUE,
+    action TEXT NOT NULL CHECK (action IN ('mask', 'reject', 'flag')),
+    reason TEXT NOT NULL DEFAULT ''
+);
+
+INSERT INTO moderation_words (id, created_at, updated_at, word, action)
+VALUES
+    (gen_random_uuid(), NOW(), NOW(), 'kerfuffle', 'mask'),
+    (gen_random_uuid(), NOW(), NOW(), 'sharbert', 'mask'),
+    (gen_random_uuid(), NOW(), NOW(), 'fornax', 'mask');
+
+CREATE TABLE chirp_flags(
+    id UUID PRIMARY KEY,
+    chirp_id UUID NOT NULL REFERENCES chirps (id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL,
+    filter TEXT NOT NULL,
+    reason TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX chirp_flags_created_at_idx ON chirp_flags (created_at);
+
+-- +goose Down
+DROP TABLE chirp_flags;
+DROP TABLE moderation_words;
@@ -0,0 +1,51 @@
+-- +goose Up
+ALTER TABLE chirps
+ADD hidden_at TIMESTAMP;
+
+ALTER TABLE users
+ADD suspended_at TIMESTAMP;
+
+CREATE TABLE reports(
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    reporter_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    reported_user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    chirp_id UUID REFERENCES chirps (id) ON DELETE SET NULL,
+    reason TEXT NOT NULL CHECK (reason IN ('spam', 'harassment', 'hate', 'violence', 'sexual', 'self_harm', 'impersonation', 'other')),
+    details TEXT NOT NULL DEFAULT '',
+    status TEXT NOT NULL DEFAULT 'open' CHECK (status IN ('open', 'resolved')),
+    assignee_id UUID REFERENCES users (id) ON DELETE SET NULL,
+    resolution TEXT CHECK (resolution IN ('dismiss', 'hide_chirp', 'suspend_user')),
+    resolved_at TIMESTAMP
+);
+
+CREATE INDEX reports_status_created_at_idx ON reports (status, created_at);
+CREATE INDEX reports_reporter_id_idx ON reports (reporter_id, created_at);
+
+-- A user can only have one open report about the same chirp or account
+CREATE UNIQUE INDEX reports_open_chirp_idx ON reports (reporter_id, chirp_id)
+WHERE status = 'open' AND chirp_id IS NOT NULL;
+CREATE UNIQUE INDEX reports_open_user_idx ON reports (reporter_id, reported_user_id)
+WHERE status = 'open' AND chirp_id IS NULL;
+
+CREATE TABLE report_decisions(
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    report_id UUID NOT NULL REFERENCES reports (id) ON DELETE CASCADE,
+    moderator_id UUID REFERENCES users (id) ON DELETE SET NULL,
+    action TEXT NOT NULL CHECK (action IN ('assign', 'unassign', 'dismiss', 'hide_chirp', 'suspend_user')),
+    note TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX report_decisions_report_id_idx ON report_decisions (report_id, created_at);
+
+-- +goose Down
+DROP TABLE report_decisions;
+DROP TABLE reports;
+
+ALTER TABLE users
+DROP COLUMN suspended_at;
+
+ALTER TABLE chirps
+DROP COLUMN hidden_at;
@@ -0,0 +1,12 @@
+-- +goose Up
+ALTER TABLE chirps
+ADD pinned_at TIMESTAMP;
+
+CREATE INDEX chirps_user_id_pinned_at_idx ON chirps (user_id, pinned_at)
+WHERE pinned_at IS NOT NULL;
+
+-- +goose Down
+DROP INDEX chirps_user_id_pinned_at_idx;
+
+ALTER TABLE chirps
+DROP COLUMN pinned_at;
@@ -0,0 +1,27 @@
+-- +goose Up
+CREATE TABLE bookmark_folders(
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    UNIQUE (user_id, name)
+);
+
+-- Bookmarks go when their chirp is purged from the trash, and fall back to
+-- no folder when their folder is deleted
+CREATE TABLE bookmarks(
+    user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    chirp_id UUID NOT NULL REFERENCES chirps (id) ON DELETE CASCADE,
+    folder_id UUID REFERENCES bookmark_folders (id) ON DELETE SET NULL,
+    created_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (user_id, chirp_id)
+);
+
+CREATE INDEX bookmarks_user_id_created_at_idx ON bookmarks (user_id, created_at DESC, chirp_id DESC);
+CREATE INDEX bookmarks_chirp_id_idx ON bookmarks (chirp_id);
+CREATE INDEX bookmarks_folder_id_idx ON bookmarks (folder_id);
+
+-- +goose Down
+DROP TABLE bookmarks;
+DROP TABLE bookmark_folders;
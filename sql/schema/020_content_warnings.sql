@@ -0,0 +1,23 @@
+-- +goose Up
+ALTER TABLE chirps
+ADD content_warning TEXT NOT NULL DEFAULT '',
+ADD content_warning_forced BOOLEAN NOT NULL DEFAULT false;
+
+ALTER TABLE media_files
+ADD sensitive BOOLEAN NOT NULL DEFAULT false;
+
+ALTER TABLE users
+ADD expand_content_warnings BOOLEAN NOT NULL DEFAULT false,
+ADD show_sensitive_media BOOLEAN NOT NULL DEFAULT false;
+
+-- +goose Down
+ALTER TABLE users
+DROP COLUMN show_sensitive_media,
+DROP COLUMN expand_content_warnings;
+
+ALTER TABLE media_files
+DROP COLUMN sensitive;
+
+ALTER TABLE chirps
+DROP COLUMN content_warning_forced,
+DROP COLUMN content_warning;
@@ -0,0 +1,9 @@
+-- +goose Up
+ALTER TABLE chirps
+ADD in_reply_to_id UUID REFERENCES chirps (id) ON DELETE SET NULL;
+
+CREATE INDEX chirps_in_reply_to_id_idx ON chirps (in_reply_to_id);
+
+-- +goose Down
+ALTER TABLE chirps
+DROP COLUMN in_reply_to_id;
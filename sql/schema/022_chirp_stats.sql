@@ -0,0 +1,18 @@
+-- +goose Up
+-- Engagement with each chirp, rolled up by the hour it happened in. user_id
+-- is the chirp's author, so an author's analytics don't need to join chirps
+CREATE TABLE chirp_stats_hourly(
+    chirp_id UUID NOT NULL REFERENCES chirps (id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    hour TIMESTAMP NOT NULL,
+    impressions BIGINT NOT NULL DEFAULT 0,
+    views BIGINT NOT NULL DEFAULT 0,
+    likes BIGINT NOT NULL DEFAULT 0,
+    replies BIGINT NOT NULL DEFAULT 0,
+    PRIMARY KEY (chirp_id, hour)
+);
+
+CREATE INDEX chirp_stats_hourly_user_id_hour_idx ON chirp_stats_hourly (user_id, hour);
+
+-- +goose Down
+DROP TABLE chirp_stats_hourly;
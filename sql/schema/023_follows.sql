@@ -0,0 +1,56 @@
+-- +goose Up
+CREATE TABLE follows(
+    follower_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    followee_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (follower_id, followee_id),
+    CHECK (follower_id <> followee_id)
+);
+
+-- One index per direction, ordered for paging through follower and
+-- following lists newest first
+CREATE INDEX follows_follower_id_created_at_idx ON follows (follower_id, created_at DESC, followee_id DESC);
+CREATE INDEX follows_followee_id_created_at_idx ON follows (followee_id, created_at DESC, follower_id DESC);
+
+ALTER TABLE users
+ADD follower_count INTEGER NOT NULL DEFAULT 0,
+ADD following_count INTEGER NOT NULL DEFAULT 0;
+
+-- Followers-only chirps are now visible to the author's followers
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION chirp_visible_to(target_chirp UUID, author UUID, chirp_visibility TEXT, viewer UUID)
+RETURNS BOOLEAN AS $$
+    SELECT chirp_visibility = 'public'
+        OR author = viewer
+        OR (chirp_visibility = 'followers' AND EXISTS (
+            SELECT 1 FROM follows
+            WHERE follows.follower_id = viewer
+            AND follows.followee_id = author
+        ))
+        OR (chirp_visibility = 'mentioned' AND EXISTS (
+            SELECT 1 FROM chirp_mentions
+            WHERE chirp_mentions.chirp_id = target_chirp
+            AND chirp_mentions.user_id = viewer
+        ));
+$$ LANGUAGE sql STABLE;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION chirp_visible_to(target_chirp UUID, author UUID, chirp_visibility TEXT, viewer UUID)
+RETURNS BOOLEAN AS $$
+    SELECT chirp_visibility = 'public'
+        OR author = viewer
+        OR (chirp_visibility = 'mentioned' AND EXISTS (
+            SELECT 1 FROM chirp_mentions
+            WHERE chirp_mentions.chirp_id = target_chirp
+            AND chirp_mentions.user_id = viewer
+        ));
+$$ LANGUAGE sql STABLE;
+-- +goose StatementEnd
+
+ALTER TABLE users
+DROP COLUMN follower_count,
+DROP COLUMN following_count;
+
+DROP TABLE follows;
@@ -0,0 +1,29 @@
+-- +goose Up
+-- Home timelines, filled in when chirps are posted (fan-out on write).
+-- created_at is the chirp's, copied here so a timeline can be paged through
+-- without reading chirps. author_id lets an unfollow clear out the
+-- unfollowed account's chirps
+CREATE TABLE timeline_entries(
+    user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    chirp_id UUID NOT NULL REFERENCES chirps (id) ON DELETE CASCADE,
+    author_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (user_id, chirp_id)
+);
+
+CREATE INDEX timeline_entries_user_id_created_at_idx ON timeline_entries (user_id, created_at DESC, chirp_id DESC);
+CREATE INDEX timeline_entries_user_id_author_id_idx ON timeline_entries (user_id, author_id);
+CREATE INDEX timeline_entries_chirp_id_idx ON timeline_entries (chirp_id);
+
+-- Start everyone off with their own chirps and those of the accounts they
+-- already follow
+INSERT INTO timeline_entries (user_id, chirp_id, author_id, created_at)
+SELECT chirps.user_id, chirps.id, chirps.user_id, chirps.created_at FROM chirps
+WHERE chirps.publish_at IS NULL
+UNION ALL
+SELECT follows.follower_id, chirps.id, chirps.user_id, chirps.created_at FROM chirps
+INNER JOIN follows ON follows.followee_id = chirps.user_id
+WHERE chirps.publish_at IS NULL;
+
+-- +goose Down
+DROP TABLE timeline_entries;
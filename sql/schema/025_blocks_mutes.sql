@@ -0,0 +1,91 @@
+-- +goose Up
+CREATE TABLE blocks(
+    blocker_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    blocked_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (blocker_id, blocked_id),
+    CHECK (blocker_id <> blocked_id)
+);
+
+CREATE INDEX blocks_blocker_id_created_at_idx ON blocks (blocker_id, created_at DESC, blocked_id DESC);
+CREATE INDEX blocks_blocked_id_idx ON blocks (blocked_id, blocker_id);
+
+-- A mute with no expires_at lasts until it is lifted
+CREATE TABLE mutes(
+    muter_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    muted_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL,
+    expires_at TIMESTAMP,
+    PRIMARY KEY (muter_id, muted_id),
+    CHECK (muter_id <> muted_id)
+);
+
+CREATE INDEX mutes_muter_id_created_at_idx ON mutes (muter_id, created_at DESC, muted_id DESC);
+
+-- A block hides each user's chirps from the other, whatever the chirp's
+-- visibility
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION chirp_visible_to(target_chirp UUID, author UUID, chirp_visibility TEXT, viewer UUID)
+RETURNS BOOLEAN AS $$
+    SELECT author = viewer
+        OR (
+            NOT EXISTS (
+                SELECT 1 FROM blocks
+                WHERE (blocks.blocker_id = author AND blocks.blocked_id = viewer)
+                OR (blocks.blocker_id = viewer AND blocks.blocked_id = author)
+            )
+            AND (
+                chirp_visibility = 'public'
+                OR (chirp_visibility = 'followers' AND EXISTS (
+                    SELECT 1 FROM follows
+                    WHERE follows.follower_id = viewer
+                    AND follows.followee_id = author
+                ))
+                OR (chirp_visibility = 'mentioned' AND EXISTS (
+                    SELECT 1 FROM chirp_mentions
+                    WHERE chirp_mentions.chirp_id = target_chirp
+                    AND chirp_mentions.user_id = viewer
+                ))
+            )
+        );
+$$ LANGUAGE sql STABLE;
+-- +goose StatementEnd
+
+-- muted_by is whether viewer has an active mute on author. Unlike blocks,
+-- mutes only keep chirps out of the viewer's feeds, so they are checked by
+-- the feed queries rather than by chirp_visible_to
+-- +goose StatementBegin
+CREATE FUNCTION muted_by(author UUID, viewer UUID)
+RETURNS BOOLEAN AS $$
+    SELECT EXISTS (
+        SELECT 1 FROM mutes
+        WHERE mutes.muter_id = viewer
+        AND mutes.muted_id = author
+        AND (mutes.expires_at IS NULL OR mutes.expires_at > NOW())
+    );
+$$ LANGUAGE sql STABLE;
+-- +goose StatementEnd
+
+-- +goose Down
+DROP FUNCTION muted_by;
+
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION chirp_visible_to(target_chirp UUID, author UUID, chirp_visibility TEXT, viewer UUID)
+RETURNS BOOLEAN AS $$
+    SELECT chirp_visibility = 'public'
+        OR author = viewer
+        OR (chirp_visibility = 'followers' AND EXISTS (
+            SELECT 1 FROM follows
+            WHERE follows.follower_id = viewer
+            AND follows.followee_id = author
+        ))
+        OR (chirp_visibility = 'mentioned' AND EXISTS (
+            SELECT 1 FROM chirp_mentions
+            WHERE chirp_mentions.chirp_id = target_chirp
+            AND chirp_mentions.user_id = viewer
+        ));
+$$ LANGUAGE sql STABLE;
+-- +goose StatementEnd
+
+DROP TABLE mutes;
+DROP TABLE blocks;
@@ -0,0 +1,89 @@
+-- +goose Up
+ALTER TABLE users
+ADD protected BOOLEAN NOT NULL DEFAULT false;
+
+-- Follows of a protected account wait here until the account approves or
+-- rejects them
+CREATE TABLE follow_requests(
+    requester_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    target_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (requester_id, target_id),
+    CHECK (requester_id <> target_id)
+);
+
+CREATE INDEX follow_requests_target_id_created_at_idx ON follow_requests (target_id, created_at DESC, requester_id DESC);
+
+-- Chirps from a protected account are only visible to its followers, on
+-- top of the chirp's own visibility
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION chirp_visible_to(target_chirp UUID, author UUID, chirp_visibility TEXT, viewer UUID)
+RETURNS BOOLEAN AS $$
+    SELECT author = viewer
+        OR (
+            NOT EXISTS (
+                SELECT 1 FROM blocks
+                WHERE (blocks.blocker_id = author AND blocks.blocked_id = viewer)
+                OR (blocks.blocker_id = viewer AND blocks.blocked_id = author)
+            )
+            AND (
+                NOT EXISTS (
+                    SELECT 1 FROM users
+                    WHERE users.id = author
+                    AND users.protected
+                )
+                OR EXISTS (
+                    SELECT 1 FROM follows
+                    WHERE follows.follower_id = viewer
+                    AND follows.followee_id = author
+                )
+            )
+            AND (
+                chirp_visibility = 'public'
+                OR (chirp_visibility = 'followers' AND EXISTS (
+                    SELECT 1 FROM follows
+                    WHERE follows.follower_id = viewer
+                    AND follows.followee_id = author
+                ))
+                OR (chirp_visibility = 'mentioned' AND EXISTS (
+                    SELECT 1 FROM chirp_mentions
+                    WHERE chirp_mentions.chirp_id = target_chirp
+                    AND chirp_mentions.user_id = viewer
+                ))
+            )
+        );
+$$ LANGUAGE sql STABLE;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION chirp_visible_to(target_chirp UUID, author UUID, chirp_visibility TEXT, viewer UUID)
+RETURNS BOOLEAN AS $$
+    SELECT author = viewer
+        OR (
+            NOT EXISTS (
+                SELECT 1 FROM blocks
+                WHERE (blocks.blocker_id = author AND blocks.blocked_id = viewer)
+                OR (blocks.blocker_id = viewer AND blocks.blocked_id = author)
+            )
+            AND (
+                chirp_visibility = 'public'
+                OR (chirp_visibility = 'followers' AND EXISTS (
+                    SELECT 1 FROM follows
+                    WHERE follows.follower_id = viewer
+                    AND follows.followee_id = author
+                ))
+                OR (chirp_visibility = 'mentioned' AND EXISTS (
+                    SELECT 1 FROM chirp_mentions
+                    WHERE chirp_mentions.chirp_id = target_chirp
+                    AND chirp_mentions.user_id = viewer
+                ))
+            )
+        );
+$$ LANGUAGE sql STABLE;
+-- +goose StatementEnd
+
+DROP TABLE follow_requests;
+
+ALTER TABLE users
+DROP COLUMN protected;
@@ -0,0 +1,46 @@
+-- +goose Up
+-- Who can start a conversation with a user: everyone, the user's followers,
+-- or nobody
+ALTER TABLE users
+ADD dm_policy TEXT NOT NULL DEFAULT 'everyone' CHECK (dm_policy IN ('everyone', 'followers', 'nobody'));
+
+-- direct_key identifies a one-to-one conversation by its two participants,
+-- so that there is only ever one per pair. It is NULL for groups
+CREATE TABLE conversations(
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    creator_id UUID REFERENCES users (id) ON DELETE SET NULL,
+    is_group BOOLEAN NOT NULL,
+    direct_key TEXT UNIQUE,
+    last_message_at TIMESTAMP NOT NULL
+);
+
+CREATE TABLE conversation_participants(
+    conversation_id UUID NOT NULL REFERENCES conversations (id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    joined_at TIMESTAMP NOT NULL,
+    last_read_at TIMESTAMP,
+    muted BOOLEAN NOT NULL DEFAULT false,
+    PRIMARY KEY (conversation_id, user_id)
+);
+
+CREATE INDEX conversation_participants_user_id_idx ON conversation_participants (user_id);
+
+-- Bodies are encrypted by the server before they are stored
+CREATE TABLE messages(
+    id UUID PRIMARY KEY,
+    conversation_id UUID NOT NULL REFERENCES conversations (id) ON DELETE CASCADE,
+    sender_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL,
+    body BYTEA NOT NULL
+);
+
+CREATE INDEX messages_conversation_id_created_at_idx ON messages (conversation_id, created_at DESC, id DESC);
+
+-- +goose Down
+DROP TABLE messages;
+DROP TABLE conversation_participants;
+DROP TABLE conversations;
+
+ALTER TABLE users
+DROP COLUMN dm_policy;
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"log"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/mjh1207/chirpy/internal/database"
+)
+
+const (
+	trendingRecentWindow    = time.Hour
+	trendingBaselineWindow  = 24 * time.Hour
+	trendingRefreshInterval = 5 * time.Minute
+	trendingLimit           = 10
+)
+
+// trendingTags holds the most recently computed trending snapshot so that
+// GET /api/trending never has to aggregate chirp_hashtags itself.
+type trendingTags struct {
+	mu   sync.RWMutex
+	tags []TrendingTag
+}
+
+func (t *trendingTags) get() []TrendingTag {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.tags
+}
+
+func (t *trendingTags) set(tags []TrendingTag) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.tags = tags
+}
+
+// runTrending recomputes the trending snapshot every trendingRefreshInterval
+// until ctx is cancelled.
+func (cfg *apiConfig) runTrending(ctx context.Context) {
+	ticker := time.NewTicker(trendingRefreshInterval)
+	defer ticker.Stop()
+	for {
+		if err := cfg.refreshTrending(ctx); err != nil {
+			log.Printf("Couldn't refresh trending tags: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// refreshTrending ranks tags by how much faster they have been used in the
+// last trendingRecentWindow than over the rest of trendingBaselineWindow.
+func (cfg *apiConfig) refreshTrending(ctx context.Context) error {
+	now := time.Now().UTC()
+	usage, err := cfg.db.GetHashtagUsage(ctx, database.GetHashtagUsageParams{
+		RecentSince:   now.Add(-trendingRecentWindow),
+		BaselineSince: now.Add(-trendingBaselineWindow),
+	})
+	if err != nil {
+		return err
+	}
+
+	tags := make([]TrendingTag, 0, len(usage))
+	for _, u := range usage {
+		score := trendingScore(u.RecentUses, u.TotalUses-u.RecentUses)
+		if score <= 0 {
+			continue
+		}
+		tags = append(tags, TrendingTag{
+			Tag:        u.Tag,
+			RecentUses: u.RecentUses,
+			Score:      score,
+		})
+	}
+	sort.Slice(tags, func(i, j int) bool { return tags[i].Score > tags[j].Score })
+	if len(tags) > trendingLimit {
+		tags = tags[:trendingLimit]
+	}
+
+	cfg.trending.set(tags)
+	return nil
+}
+
+// trendingScore compares the hourly rate of recent uses against the hourly
+// rate of earlier uses in the baseline window. Dividing by the square root of
+// the earlier rate keeps already-popular tags from dominating on volume alone.
+func trendingScore(recentUses, earlierUses int64) float64 {
+	recentRate := float64(recentUses) / trendingRecentWindow.Hours()
+	earlierRate := float64(earlierUses) / (trendingBaselineWindow - trendingRecentWindow).Hours()
+	return (recentRate - earlierRate) / math.Sqrt(earlierRate+1)
+}
@@ -4,14 +4,20 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/mjh1207/chirpy/internal/entitlements"
 )
 
 type User struct {
 	ID uuid.UUID `json:"id"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
-	Email string `json:"email"`
+	Email string `json:"email,omitempty"`
 	IsChirpyRed bool `json:"is_chirpy_red"`
+	Handle string `json:"handle,omitempty"`
+	FollowerCount int32 `json:"follower_count"`
+	FollowingCount int32 `json:"following_count"`
+	Protected bool `json:"protected"`
+	DMPolicy string `json:"dm_policy,omitempty"`
 }
 
 type Chirp struct {
@@ -20,8 +26,315 @@ type Chirp struct {
 	UpdatedAt time.Time `json:"updated_at"`
 	Body string `json:"body"`
 	User_Id string `json:"user_id"`
+	InReplyToID *uuid.UUID `json:"in_reply_to_id,omitempty"`
+	LikeCount int32 `json:"like_count"`
+	Visibility string `json:"visibility"`
+	Pinned bool `json:"pinned"`
+	ContentWarning string `json:"content_warning,omitempty"`
+	ContentWarningForced bool `json:"content_warning_forced,omitempty"`
+	Collapsed bool `json:"collapsed"`
+	LikedByMe *bool `json:"liked_by_me,omitempty"`
+	Entities Entities `json:"entities"`
+	Media []Media `json:"media"`
+	PublishAt *time.Time `json:"publish_at,omitempty"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+	HiddenAt *time.Time `json:"hidden_at,omitempty"`
+	Poll *Poll `json:"poll,omitempty"`
+}
+
+// Poll is the poll attached to a chirp. Votes and TotalVotes are left out
+// until the viewer has voted or the poll has closed.
+type Poll struct {
+	ClosesAt time.Time `json:"closes_at"`
+	Closed bool `json:"closed"`
+	Options []PollOption `json:"options"`
+	TotalVotes *int32 `json:"total_votes,omitempty"`
+	VotedOption *int32 `json:"voted_option,omitempty"`
+}
+
+type PollOption struct {
+	Label string `json:"label"`
+	Votes *int32 `json:"votes,omitempty"`
+}
+
+// Entities describes the structured parts of a chirp body. Indices are
+// [start, end) rune offsets into the body.
+type Entities struct {
+	Hashtags []HashtagEntity `json:"hashtags"`
+	Mentions []MentionEntity `json:"mentions"`
+	URLs []URLEntity `json:"urls"`
+}
+
+type HashtagEntity struct {
+	Tag string `json:"tag"`
+	Indices [2]int `json:"indices"`
+}
+
+type MentionEntity struct {
+	Handle string `json:"handle"`
+	UserID uuid.UUID `json:"user_id"`
+	Indices [2]int `json:"indices"`
+}
+
+type URLEntity struct {
+	URL string `json:"url"`
+	Indices [2]int `json:"indices"`
+}
+
+type Draft struct {
+	ID uuid.UUID `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Body string `json:"body"`
+	MediaIDs []uuid.UUID `json:"media_ids"`
+}
+
+type Media struct {
+	ID uuid.UUID `json:"id"`
+	URL string `json:"url"`
+	ThumbnailURL string `json:"thumbnail_url"`
+	MimeType string `json:"mime_type"`
+	Width int32 `json:"width"`
+	Height int32 `json:"height"`
+	AltText string `json:"alt_text"`
+	Sensitive bool `json:"sensitive"`
+}
+
+type TrendingTag struct {
+	Tag string `json:"tag"`
+	RecentUses int64 `json:"recent_uses"`
+	Score float64 `json:"score"`
 }
 
 type AccessToken struct {
 	Token string `json:"token"`
+}
+type Entitlements struct {
+	Tier entitlements.Tier `json:"tier"`
+	entitlements.Entitlements
+}
+
+type ModerationWord struct {
+	ID uuid.UUID `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	Word string `json:"word"`
+	Action string `json:"action"`
+	Reason string `json:"reason"`
+}
+
+type ChirpFlag struct {
+	ID uuid.UUID `json:"id"`
+	ChirpID uuid.UUID `json:"chirp_id"`
+	CreatedAt time.Time `json:"created_at"`
+	Filter string `json:"filter"`
+	Reason string `json:"reason"`
+}
+
+type Report struct {
+	ID uuid.UUID `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	ReporterID *uuid.UUID `json:"reporter_id,omitempty"`
+	ReportedUserID uuid.UUID `json:"reported_user_id"`
+	ChirpID *uuid.UUID `json:"chirp_id,omitempty"`
+	Reason string `json:"reason"`
+	Details string `json:"details"`
+	Status string `json:"status"`
+	Resolution string `json:"resolution,omitempty"`
+	ResolvedAt *time.Time `json:"resolved_at,omitempty"`
+	AssigneeID *uuid.UUID `json:"assignee_id,omitempty"`
+	Decisions []ReportDecision `json:"decisions,omitempty"`
+}
+
+type ReportDecision struct {
+	CreatedAt time.Time `json:"created_at"`
+	ModeratorID *uuid.UUID `json:"moderator_id,omitempty"`
+	Action string `json:"action"`
+	Note string `json:"note"`
+}
+
+type BookmarkFolder struct {
+	ID uuid.UUID `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Name string `json:"name"`
+}
+
+type Bookmark struct {
+	FolderID *uuid.UUID `json:"folder_id"`
+	BookmarkedAt time.Time `json:"bookmarked_at"`
+	Chirp *Chirp `json:"chirp"`
+}
+
+// BookmarkPage is one page of a user's bookmarks. NextCursor is left out on
+// the last page.
+type BookmarkPage struct {
+	Bookmarks []Bookmark `json:"bookmarks"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// Preferences control how chirps are presented to a reader.
+type Preferences struct {
+	ExpandContentWarnings bool `json:"expand_content_warnings"`
+	ShowSensitiveMedia bool `json:"show_sensitive_media"`
+}
+
+// ChirpRecord is one line of a chirp export, and of an import.
+type ChirpRecord struct {
+	ID uuid.UUID `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	UserID uuid.UUID `json:"user_id"`
+	Body string `json:"body"`
+	Visibility string `json:"visibility"`
+	PublishAt *time.Time `json:"publish_at,omitempty"`
+	ContentWarning string `json:"content_warning,omitempty"`
+	InReplyToID *uuid.UUID `json:"in_reply_to_id,omitempty"`
+}
+
+// ImportReport is the outcome of a chirp import. Nothing is imported when
+// there are errors.
+type ImportReport struct {
+	DryRun bool `json:"dry_run"`
+	Lines int `json:"lines"`
+	Imported int `json:"imported"`
+	Errors []ImportError `json:"errors"`
+}
+
+type ImportError struct {
+	Line int `json:"line"`
+	Error string `json:"error"`
+}
+
+type ChirpStats struct {
+	Impressions int64 `json:"impressions"`
+	Views int64 `json:"views"`
+	Likes int64 `json:"likes"`
+	Replies int64 `json:"replies"`
+}
+
+type StatsBucket struct {
+	Time time.Time `json:"time"`
+	ChirpStats
+}
+
+type ChirpStatsBreakdown struct {
+	ChirpID uuid.UUID `json:"chirp_id"`
+	ChirpStats
+}
+
+type Analytics struct {
+	Since time.Time `json:"since"`
+	Until time.Time `json:"until"`
+	Interval string `json:"interval"`
+	Totals ChirpStats `json:"totals"`
+	Series []StatsBucket `json:"series"`
+	Chirps []ChirpStatsBreakdown `json:"chirps,omitempty"`
+}
+
+type Follow struct {
+	User User `json:"user"`
+	FollowedAt time.Time `json:"followed_at"`
+}
+
+type FollowPage struct {
+	Users []Follow `json:"users"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+type TimelinePage struct {
+	Chirps []Chirp `json:"chirps"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+type Block struct {
+	User User `json:"user"`
+	BlockedAt time.Time `json:"blocked_at"`
+}
+
+type BlockPage struct {
+	Users []Block `json:"users"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+type Mute struct {
+	User User `json:"user"`
+	MutedAt time.Time `json:"muted_at"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+type MutePage struct {
+	Users []Mute `json:"users"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+type FollowRequest struct {
+	User User `json:"user"`
+	RequestedAt time.Time `json:"requested_at"`
+}
+
+type FollowRequestPage struct {
+	Users []FollowRequest `json:"users"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+type List struct {
+	ID uuid.UUID `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	OwnerID uuid.UUID `json:"owner_id"`
+	Name string `json:"name"`
+	Visibility string `json:"visibility"`
+	MemberCount int32 `json:"member_count"`
+	SubscriberCount int32 `json:"subscriber_count"`
+}
+
+type ListPage struct {
+	Lists []List `json:"lists"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+type ListMember struct {
+	User User `json:"user"`
+	AddedAt time.Time `json:"added_at"`
+}
+
+type ListMemberPage struct {
+	Users []ListMember `json:"users"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+type Conversation struct {
+	ID uuid.UUID `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	IsGroup bool `json:"is_group"`
+	Participants []ConversationParticipant `json:"participants"`
+	LastMessageAt time.Time `json:"last_message_at"`
+	UnreadCount int64 `json:"unread_count"`
+	Muted bool `json:"muted"`
+}
+
+type ConversationParticipant struct {
+	User User `json:"user"`
+	LastReadAt *time.Time `json:"last_read_at,omitempty"`
+}
+
+type ConversationPage struct {
+	Conversations []Conversation `json:"conversations"`
+	UnreadConversations int64 `json:"unread_conversations"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+type Message struct {
+	ID uuid.UUID `json:"id"`
+	ConversationID uuid.UUID `json:"conversation_id"`
+	SenderID uuid.UUID `json:"sender_id"`
+	CreatedAt time.Time `json:"created_at"`
+	Body string `json:"body"`
+	ReadBy []uuid.UUID `json:"read_by"`
+}
+
+type MessagePage struct {
+	Messages []Message `json:"messages"`
+	NextCursor string `json:"next_cursor,omitempty"`
 }
\ No newline at end of file